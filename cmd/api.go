@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var (
+	apiGraph string
+	apiNode  string
+	apiJSON  bool // if true, print [{name, file}] as JSON
+)
+
+// apiCmd lists a package's public surface: every name its entry file exports,
+// through any number of re-export barrels, with the file that declares it.
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "List the names an entry file exports, following re-export barrels, from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if apiGraph == "" || apiNode == "" {
+			return fmt.Errorf("--graph and --node are required")
+		}
+		g, err := loadGraph(apiGraph)
+		if err != nil {
+			return err
+		}
+		node, err := resolveNodeArg(viper.GetString("root"), g, apiNode, apiGraph)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		exports := scan.PublicAPI(g, node)
+		if apiJSON {
+			return newJSONEncoder(os.Stdout).Encode(exports)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, e := range exports {
+			fmt.Fprintf(tw, "%s\t%s\n", e.Name, e.File)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.Flags().StringVar(&apiGraph, "graph", "", "path to graph.json to analyze")
+	apiCmd.Flags().StringVar(&apiNode, "node", "", "entry file of the package (file path as in the graph, or relative to --root)")
+	apiCmd.Flags().BoolVar(&apiJSON, "json", false, "print [{name, file}] as JSON")
+	addCompactFlag(apiCmd)
+}
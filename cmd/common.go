@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	commonGraph string
+	commonNodes []string
+	commonJSON  bool // if true, print the shared dependencies as a JSON array
+)
+
+// commonCmd lists what two files both depend on: the shared modules a refactor
+// spanning both would have to account for.
+var commonCmd = &cobra.Command{
+	Use:   "common",
+	Short: "List the dependencies two nodes share, from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if commonGraph == "" || len(commonNodes) != 2 {
+			return fmt.Errorf("--graph and exactly two --node flags are required")
+		}
+		g, err := loadGraph(commonGraph)
+		if err != nil {
+			return err
+		}
+		nodes := make([]string, len(commonNodes))
+		for i, arg := range commonNodes {
+			if nodes[i], err = resolveNodeArg(viper.GetString("root"), g, arg, commonGraph); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+		common := g.CommonDependencies(nodes[0], nodes[1])
+		if commonJSON {
+			return newJSONEncoder(os.Stdout).Encode(common)
+		}
+		for _, n := range common {
+			fmt.Println(n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commonCmd)
+	commonCmd.Flags().StringVar(&commonGraph, "graph", "", "path to graph.json to analyze")
+	commonCmd.Flags().StringArrayVar(&commonNodes, "node", nil, "node to compare (give twice; file path as in the graph, or relative to --root)")
+	commonCmd.Flags().BoolVar(&commonJSON, "json", false, "print the shared dependencies as a JSON array")
+	addCompactFlag(commonCmd)
+}
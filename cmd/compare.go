@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+var (
+	compareModules    string
+	compareComponents string
+)
+
+// compareCmd reports where a module (import) graph and a component (JSX usage)
+// graph of the same code disagree.
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare a module graph with a component graph: imports never rendered, renders without an import",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if compareModules == "" || compareComponents == "" {
+			return fmt.Errorf("--modules and --components are required (paths to graph.json files)")
+		}
+		modules, err := loadGraph(compareModules)
+		if err != nil {
+			return err
+		}
+		components, err := loadGraph(compareComponents)
+		if err != nil {
+			return err
+		}
+		notRendered, notImported := compareGraphs(modules, fileLevel(components))
+		printEdgeList(os.Stdout, "imported but never rendered", notRendered)
+		printEdgeList(os.Stdout, "rendered without an import path", notImported)
+		return nil
+	},
+}
+
+// fileLevel collapses split component nodes ("file.tsx#Name") onto their files and
+// keeps only render edges (dropping "imported" ones from --import-edges).
+func fileLevel(g *graph.Graph) *graph.Graph {
+	file := func(n string) string {
+		if i := strings.LastIndex(n, "#"); i > 0 && !strings.HasPrefix(n, "pkg:") {
+			return n[:i]
+		}
+		return n
+	}
+	out := graph.New()
+	for _, n := range g.Nodes() {
+		out.Touch(file(n))
+	}
+	g.ForEachEdge(func(from, to string) {
+		if g.EdgeKind(from, to) != graph.EdgeImported {
+			out.AddEdge(file(from), file(to))
+		}
+	})
+	return out
+}
+
+// compareGraphs returns module edges from files in the component graph to component
+// files (.tsx/.jsx, or files in the component graph) with no render edge: imports
+// that are never rendered. It also returns render edges whose target the importer
+// can't reach through imports at all: renders via non-import means. Targets reached
+// through barrels count as imported. External pkg: nodes are ignored on both sides.
+func compareGraphs(modules, components *graph.Graph) (notRendered, notImported [][2]string) {
+	isComponentFile := func(n string) bool {
+		if components.Has(n) {
+			return true
+		}
+		ext := strings.ToLower(filepath.Ext(n))
+		return ext == ".tsx" || ext == ".jsx"
+	}
+	modules.ForEachEdge(func(from, to string) {
+		if graph.KindOf(to) == graph.KindExternal || !components.Has(from) || !isComponentFile(to) {
+			return
+		}
+		if !slices.Contains(components.OutNeighbors(from), to) {
+			notRendered = append(notRendered, [2]string{from, to})
+		}
+	})
+	deps := map[string][]string{}
+	components.ForEachEdge(func(from, to string) {
+		if graph.KindOf(to) == graph.KindExternal {
+			return
+		}
+		d, ok := deps[from]
+		if !ok {
+			d = modules.Dependencies(from)
+			deps[from] = d
+		}
+		if _, found := slices.BinarySearch(d, to); !found {
+			notImported = append(notImported, [2]string{from, to})
+		}
+	})
+	sortEdges := func(es [][2]string) {
+		slices.SortFunc(es, func(a, b [2]string) int {
+			if c := strings.Compare(a[0], b[0]); c != 0 {
+				return c
+			}
+			return strings.Compare(a[1], b[1])
+		})
+	}
+	sortEdges(notRendered)
+	sortEdges(notImported)
+	return notRendered, notImported
+}
+
+// printEdgeList prints a titled, counted list of edges as "from -> to".
+func printEdgeList(w io.Writer, title string, edges [][2]string) {
+	fmt.Fprintf(w, "%s (%d):\n", title, len(edges))
+	for _, e := range edges {
+		fmt.Fprintf(w, "  %s -> %s\n", e[0], e[1])
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().StringVar(&compareModules, "modules", "", "module graph.json (from scan or entries)")
+	compareCmd.Flags().StringVar(&compareComponents, "components", "", "component graph.json (from components)")
+}
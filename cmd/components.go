@@ -2,20 +2,31 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/philjestin/philtographer/internal/graph"
 	"github.com/philjestin/philtographer/internal/scan"
-	"github.com/philjestin/philtographer/internal/scan/providers"
 	"github.com/philjestin/philtographer/internal/tsgraph"
 )
 
+var (
+	componentsSplit      bool          // if true, emit one node per declared component instead of per file
+	componentsExternals  bool          // if true, keep JSX usages of package imports as edges to pkg: nodes
+	componentsVerbose    bool          // if true, list JSX identifiers that couldn't be linked to a file
+	componentsImports    bool          // if true, also record imported-but-never-rendered components
+	componentsCheckpoint time.Duration // if > 0, rewrite --out with the partial graph this often
+)
+
 var componentsCmd = &cobra.Command{
 	Use:   "components",
 	Short: "Build a React component graph (TSX) using tree-sitter and output JSON",
@@ -27,41 +38,37 @@ var componentsCmd = &cobra.Command{
 		if cfg.Root == "" {
 			cfg.Root = "."
 		}
+		mode, err := relativeIDsMode(cfg.RelativeIDs)
+		if err != nil {
+			return err
+		}
+		cfg.RelativeIDs = mode
 		out := viper.GetString("out")
 		if out == "" && cfg.Out != "" {
 			out = cfg.Out
 		}
 
 		// Build providers from config (reuse logic from entries command)
-		var provs []providers.Provider
-		for _, spec := range cfg.Entries {
-			switch spec.Type {
-			case "rootsTs":
-				provs = append(provs, providers.RootsTsProvider{File: spec.File, NameFrom: spec.NameFrom})
-			case "explicit":
-				provs = append(provs, providers.ExplicitProvider{Name: spec.Name, Path: spec.Path})
-			default:
-				return fmt.Errorf("unknown entry provider type: %s", spec.Type)
-			}
+		provs, err := buildProviders(cfg.Entries)
+		if err != nil {
+			return err
+		}
+
+		if componentsCheckpoint > 0 && out == "" {
+			return fmt.Errorf("--checkpoint needs --out (the file checkpoints are written to)")
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		// Ctrl-C stops the walk; whatever was built so far is still written.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Minute)
 		defer cancel()
 
-		seen := map[string]bool{}
-		var entryPaths []string
-		for _, p := range provs {
-			es, err := p.Discover(ctx, cfg.Root)
-			if err != nil {
-				return err
-			}
-			for _, e := range es {
-				if !seen[e.Path] {
-					seen[e.Path] = true
-					entryPaths = append(entryPaths, e.Path)
-				}
-			}
+		entries, err := discoverEntries(ctx, cfg.Root, provs, cfg.StrictEntries)
+		if err != nil {
+			return err
 		}
+		entryPaths := entryPaths(entries)
 
 		// If no providers configured or they yielded nothing, fallback to cfg.Root as an entry.
 		if len(entryPaths) == 0 && cfg.Root != "" {
@@ -75,10 +82,7 @@ var componentsCmd = &cobra.Command{
 					}
 				}
 			}
-			if !seen[rootEntry] {
-				seen[rootEntry] = true
-				entryPaths = append(entryPaths, rootEntry)
-			}
+			entryPaths = append(entryPaths, rootEntry)
 		}
 
 		if len(entryPaths) == 0 {
@@ -96,32 +100,122 @@ var componentsCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "\rcomponents: visited=%d edges=%d queued=%d", visited, edges, queued)
 		}
 
-		g, err := tsgraph.BuildComponentGraphFromEntriesProgress(ctx, cfg.Root, entryPaths, progress)
+		var checkpoint func(*graph.Graph)
+		if componentsCheckpoint > 0 {
+			checkpoint = func(partial *graph.Graph) {
+				partial, _ = withRelativeIDs(partial, cfg.Root, cfg.RelativeIDs)
+				if err := writeCheckpoint(out, partial); err != nil {
+					logger.Warn("checkpoint failed", "path", out, "err", err)
+				}
+			}
+		}
+
+		var unresolved []tsgraph.Unresolved
+		start := time.Now()
+		stopPeak := peakHeap()
+		g, err := tsgraph.BuildComponentGraphWithOptions(ctx, cfg.Root, entryPaths, tsgraph.Options{
+			Progress:        progress,
+			SplitComponents: componentsSplit,
+			ExternalUsages:  componentsExternals,
+			ImportEdges:     componentsImports,
+			Checkpoint:      checkpoint,
+			CheckpointEvery: componentsCheckpoint,
+			Unresolved: func(u tsgraph.Unresolved) {
+				unresolved = append(unresolved, u)
+			},
+		})
+		peak := stopPeak()
 		// finish the progress line
 		fmt.Fprintln(os.Stderr)
-		if err != nil && err != context.Canceled {
+		// A cancelled or timed-out build still returns what it found; keep it.
+		interrupted := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+		if err != nil && !interrupted {
 			return err
 		}
-
-		var enc *json.Encoder
-		if out != "" {
-			f, err := os.Create(out)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-			enc = json.NewEncoder(f)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(g); err != nil {
-				return err
+		if interrupted {
+			logger.Warn("build interrupted; writing the partial graph", "err", err)
+		}
+		printSummary(os.Stderr, "components", g, nil, time.Since(start))
+		if memStats {
+			printPeakHeap(os.Stderr, "components", peak)
+		}
+		if componentsVerbose {
+			printUnresolvedComponents(os.Stderr, unresolved)
+			if componentsImports {
+				printImportOnly(os.Stderr, g)
 			}
-			fmt.Fprintf(os.Stderr, "wrote %s\n", out)
-			return nil
 		}
-		enc = json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(g)
+
+		g, relErr := withRelativeIDs(g, cfg.Root, cfg.RelativeIDs)
+		if relErr != nil {
+			return relErr
+		}
+		if err := writeGraph(out, g); err != nil {
+			return err
+		}
+		if interrupted {
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
 	},
 }
 
-func init() { rootCmd.AddCommand(componentsCmd) }
+// writeCheckpoint replaces out with g through a temporary file, so a run stopped
+// mid-write never leaves a truncated graph behind.
+func writeCheckpoint(out string, g *graph.Graph) error {
+	if err := replaceFile(out, func(w io.Writer) error { return encodeGraph(w, g) }); err != nil {
+		return err
+	}
+	logger.Debug("checkpoint", "path", out, "nodes", len(g.Nodes()))
+	return nil
+}
+
+// printUnresolvedComponents reports JSX usages that weren't linked, grouped by file.
+func printUnresolvedComponents(w io.Writer, unresolved []tsgraph.Unresolved) {
+	sort.Slice(unresolved, func(i, j int) bool {
+		if unresolved[i].File != unresolved[j].File {
+			return unresolved[i].File < unresolved[j].File
+		}
+		return unresolved[i].Ident < unresolved[j].Ident
+	})
+	files := map[string]bool{}
+	for _, u := range unresolved {
+		files[u.File] = true
+	}
+	fmt.Fprintf(w, "components: %d unresolved JSX identifier(s) in %d file(s)\n", len(unresolved), len(files))
+	for _, u := range unresolved {
+		if u.Module == "" {
+			fmt.Fprintf(w, "  %s: <%s> (not imported)\n", u.File, u.Ident)
+		} else {
+			fmt.Fprintf(w, "  %s: <%s> from %q\n", u.File, u.Ident, u.Module)
+		}
+	}
+}
+
+// printImportOnly lists edges tagged imported: components imported but never rendered.
+func printImportOnly(w io.Writer, g *graph.Graph) {
+	var lines []string
+	g.ForEachEdge(func(from, to string) {
+		if g.EdgeKind(from, to) == graph.EdgeImported {
+			lines = append(lines, fmt.Sprintf("  %s: imports %s", from, to))
+		}
+	})
+	sort.Strings(lines)
+	fmt.Fprintf(w, "components: %d component import(s) never rendered\n", len(lines))
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(componentsCmd)
+	addOutputFlags(componentsCmd)
+	addRelativeIDsFlag(componentsCmd)
+	componentsCmd.Flags().BoolVar(&componentsExternals, "external-usages", false, "add edges to pkg: nodes for JSX usages of identifiers imported from packages")
+	componentsCmd.Flags().BoolVar(&componentsImports, "import-edges", false, "also add edges for imported components that are never rendered; tags edges rendered or imported")
+	componentsCmd.Flags().BoolVar(&componentsVerbose, "verbose", false, "list JSX component usages that couldn't be linked to a file")
+	componentsCmd.Flags().DurationVar(&componentsCheckpoint, "checkpoint", 0, "rewrite --out with the graph built so far at this interval (e.g. 5s), so an interrupted run leaves a usable partial graph")
+	componentsCmd.Flags().BoolVar(&memStats, "mem-stats", false, "print the peak heap of the build after the summary")
+	componentsCmd.Flags().BoolVar(&componentsSplit, "split-components", false, "one node per declared component (file.tsx#Name) instead of per file")
+}
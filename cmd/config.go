@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// applyExtends merges the configs named by "extends" (resolved relative to the file
+// that names them, recursively) underneath the config viper loaded. Local settings
+// override inherited ones, maps merge key by key, and "entries" lists are
+// concatenated, parents first, so a shared base can list common entries once.
+func applyExtends() error {
+	if viper.GetString("extends") == "" {
+		return nil
+	}
+	merged, err := readConfigChain(viper.ConfigFileUsed(), map[string]bool{})
+	if err != nil {
+		return err
+	}
+	return viper.MergeConfigMap(merged)
+}
+
+// readConfigChain reads path and everything it extends, returning the merged settings.
+func readConfigChain(path string, seen map[string]bool) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if seen[abs] {
+		return nil, fmt.Errorf("config extends cycle at %s", path)
+	}
+	seen[abs] = true
+
+	v := viper.New()
+	v.SetConfigFile(abs)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+	settings := v.AllSettings()
+	parent, _ := settings["extends"].(string)
+	delete(settings, "extends")
+	if parent == "" {
+		return settings, nil
+	}
+	if !filepath.IsAbs(parent) {
+		parent = filepath.Join(filepath.Dir(abs), parent)
+	}
+	base, err := readConfigChain(parent, seen)
+	if err != nil {
+		return nil, fmt.Errorf("%s extends: %w", path, err)
+	}
+	return mergeConfig(base, settings), nil
+}
+
+// mergeConfig overlays over onto base (see applyExtends for the rules).
+func mergeConfig(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		switch bv := out[k].(type) {
+		case map[string]any:
+			if ov, ok := v.(map[string]any); ok {
+				out[k] = mergeConfig(bv, ov)
+				continue
+			}
+		case []any:
+			if ov, ok := v.([]any); ok && k == "entries" {
+				out[k] = append(append([]any{}, bv...), ov...)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
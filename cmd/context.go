@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	contextGraph           string
+	contextNode            string
+	contextDepsDepth       int
+	contextDependentsDepth int
+	contextJSON            bool // if true, print {dependencies, dependents} as JSON instead of text
+)
+
+// nodeContext is everything connected to a node: what it imports and what
+// imports it, each with its distance.
+type nodeContext struct {
+	Dependencies []nodeDepth `json:"dependencies"`
+	Dependents   []nodeDepth `json:"dependents"`
+}
+
+// contextCmd answers "what is this file connected to" in one go: dependencies
+// and dependents together, each with its own depth limit.
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "List what a node depends on and what depends on it, from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if contextGraph == "" || contextNode == "" {
+			return fmt.Errorf("--graph and --node are required")
+		}
+		g, err := loadGraph(contextGraph)
+		if err != nil {
+			return err
+		}
+		node, err := resolveNodeArg(viper.GetString("root"), g, contextNode, contextGraph)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		out := nodeContext{
+			Dependencies: byDepth(g.DependenciesDepth(node, contextDepsDepth)),
+			Dependents:   byDepth(g.ImpactedDepth(node, contextDependentsDepth)),
+		}
+		if contextJSON {
+			return newJSONEncoder(os.Stdout).Encode(out)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "dependencies (%d):\n", len(out.Dependencies))
+		for _, d := range out.Dependencies {
+			fmt.Fprintf(tw, "  %d\t%s\n", d.Depth, d.Node)
+		}
+		fmt.Fprintf(tw, "dependents (%d):\n", len(out.Dependents))
+		for _, d := range out.Dependents {
+			fmt.Fprintf(tw, "  %d\t%s\n", d.Depth, d.Node)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.Flags().StringVar(&contextGraph, "graph", "", "path to graph.json to analyze")
+	contextCmd.Flags().StringVar(&contextNode, "node", "", "node to describe (file path as in the graph, or relative to --root)")
+	contextCmd.Flags().IntVar(&contextDepsDepth, "deps-depth", 0, "only list dependencies within this many hops (0 = all)")
+	contextCmd.Flags().IntVar(&contextDependentsDepth, "dependents-depth", 0, "only list dependents within this many hops (0 = all)")
+	contextCmd.Flags().BoolVar(&contextJSON, "json", false, "print {dependencies, dependents} as JSON")
+	addCompactFlag(contextCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+var cyclesGraph string
+
+// cyclesCmd prints circular imports from a graph.json file and exits non-zero if any exist.
+var cyclesCmd = &cobra.Command{
+	Use:   "cycles",
+	Short: "Print circular dependencies among internal files; exits non-zero if any are found",
+	// A found cycle is the expected failure mode here, not a usage mistake.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cyclesGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		g, err := loadGraph(cyclesGraph)
+		if err != nil {
+			return err
+		}
+		return checkCycles(os.Stdout, g)
+	},
+}
+
+// internalCycles returns g's cycles, ignoring any made up solely of pkg: externals.
+func internalCycles(g *graph.Graph) [][]string {
+	var out [][]string
+	for _, c := range g.Cycles() {
+		for _, n := range c {
+			if !strings.HasPrefix(n, "pkg:") {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// checkCycles prints each internal cycle as "a -> b -> a" and returns an error if there were any.
+func checkCycles(w io.Writer, g *graph.Graph) error {
+	cycles := internalCycles(g)
+	for _, c := range cycles {
+		fmt.Fprintln(w, strings.Join(append(c, c[0]), " -> "))
+	}
+	if len(cycles) > 0 {
+		return fmt.Errorf("found %d circular dependency cycle(s)", len(cycles))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(cyclesCmd)
+	cyclesCmd.Flags().StringVar(&cyclesGraph, "graph", "", "path to graph.json to analyze")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	depsGraph string
+	depsNode  string
+	depsDepth int
+	depsJSON  bool // if true, print [{node, depth}] as JSON instead of text
+)
+
+// nodeDepth is a node connected to the one asked about, with its distance in
+// imports.
+type nodeDepth struct {
+	Node  string `json:"node"`
+	Depth int    `json:"depth"`
+}
+
+// dependentsCmd lists everything that imports a node, directly or transitively:
+// what to check before changing a shared module.
+var dependentsCmd = &cobra.Command{
+	Use:   "dependents",
+	Short: "List files that import a node, directly or transitively, from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if depsGraph == "" || depsNode == "" {
+			return fmt.Errorf("--graph and --node are required")
+		}
+		g, err := loadGraph(depsGraph)
+		if err != nil {
+			return err
+		}
+		node, err := resolveNodeArg(viper.GetString("root"), g, depsNode, depsGraph)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		out := byDepth(g.ImpactedDepth(node, depsDepth))
+		if depsJSON {
+			return newJSONEncoder(os.Stdout).Encode(out)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, d := range out {
+			fmt.Fprintf(tw, "%d\t%s\n", d.Depth, d.Node)
+		}
+		return tw.Flush()
+	},
+}
+
+// byDepth lists the nodes in dist nearest first, ties by path.
+func byDepth(dist map[string]int) []nodeDepth {
+	out := make([]nodeDepth, 0, len(dist))
+	for n, d := range dist {
+		out = append(out, nodeDepth{Node: n, Depth: d})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Depth != out[j].Depth {
+			return out[i].Depth < out[j].Depth
+		}
+		return out[i].Node < out[j].Node
+	})
+	return out
+}
+
+func init() {
+	rootCmd.AddCommand(dependentsCmd)
+	dependentsCmd.Flags().StringVar(&depsGraph, "graph", "", "path to graph.json to analyze")
+	dependentsCmd.Flags().StringVar(&depsNode, "node", "", "node to list importers of (file path as in the graph, or relative to --root)")
+	dependentsCmd.Flags().IntVar(&depsDepth, "depth", 0, "only list importers within this many hops (0 = all)")
+	dependentsCmd.Flags().BoolVar(&depsJSON, "json", false, "print [{node, depth}] as JSON")
+	addCompactFlag(dependentsCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+// diagnostic is one problem with an import, for editors to show at its line.
+type diagnostic struct {
+	Spec     string `json:"spec"`
+	Line     int    `json:"line,omitempty"` // 1-based; omitted when the spec wasn't found in the file
+	Severity string `json:"severity"`       // "error" or "warning"
+	Code     string `json:"code"`           // "unresolved", "self-import", "outside-root", or "dynamic-unresolvable"
+	Message  string `json:"message"`
+}
+
+// diagnosticsFile is the --diagnostics output: every file with problems, by path.
+type diagnosticsFile struct {
+	Files map[string][]diagnostic `json:"files"`
+}
+
+// collectDiagnostics turns the manifest's unresolved imports, self-imports,
+// out-of-root escapes, and computed dynamic imports into per-file diagnostics,
+// sorted by line. Each file with diagnostics is read once to locate its specs.
+func collectDiagnostics(m *scan.Manifest) diagnosticsFile {
+	out := diagnosticsFile{Files: map[string][]diagnostic{}}
+	add := func(file string, d diagnostic) {
+		out.Files[file] = append(out.Files[file], d)
+	}
+	for _, u := range m.Unresolved {
+		add(u.File, diagnostic{Spec: u.Spec, Severity: "error", Code: "unresolved", Message: u.Err.Error()})
+	}
+	for _, s := range m.SelfImport {
+		add(s.File, diagnostic{Spec: s.Spec, Severity: "warning", Code: "self-import", Message: "import resolves to this file itself"})
+	}
+	for _, o := range m.OutOfRoot {
+		add(o.File, diagnostic{Spec: o.Spec, Severity: "warning", Code: "outside-root", Message: "import resolves outside the scan root: " + o.To})
+	}
+	for _, c := range m.Computed {
+		add(c.File, diagnostic{Spec: c.Expr, Line: c.Line, Severity: "warning", Code: "dynamic-unresolvable", Message: "import target is computed at runtime; its edges are missing from the graph"})
+	}
+	for file, diags := range out.Files {
+		data, _ := os.ReadFile(file)
+		for i := range diags {
+			if diags[i].Line == 0 {
+				diags[i].Line = specLine(data, diags[i].Spec)
+			}
+		}
+		sort.SliceStable(diags, func(i, j int) bool { return diags[i].Line < diags[j].Line })
+	}
+	return out
+}
+
+// specLine returns the 1-based line of the first quoted occurrence of spec in
+// data, or 0.
+func specLine(data []byte, spec string) int {
+	for _, q := range []string{`'`, `"`, "`"} {
+		if i := bytes.Index(data, []byte(q+spec+q)); i >= 0 {
+			return bytes.Count(data[:i], []byte("\n")) + 1
+		}
+	}
+	return 0
+}
+
+// writeDiagnostics writes m's diagnostics as JSON to path.
+func writeDiagnostics(path string, m *scan.Manifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := newJSONEncoder(f).Encode(collectDiagnostics(m)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logger.Info("wrote diagnostics", "path", path)
+	return nil
+}
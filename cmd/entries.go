@@ -2,22 +2,32 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/philjestin/philtographer/internal/graph"
 	"github.com/philjestin/philtographer/internal/scan"
-	"github.com/philjestin/philtographer/internal/scan/providers"
 )
 
 // CLI flags (local to this subcommand)
 var (
-	printEntries bool // if true, list discovered entries then exit (no graph build)
-	verbose      bool // if true, print extra diagnostics to stderr
+	printEntries bool     // if true, list discovered entries then exit (no graph build)
+	entriesFile  string   // extra entries list (text or JSON), same as a "file" entry spec
+	verbose      bool     // if true, print how specifiers were resolved
+	perEntry     bool     // if true, build and write one graph per entry instead of a merged graph
+	dropEntries  bool     // if true, leave the entry files themselves out of the written graph
+	noExternals  bool     // if true, don't record pkg: externals at all
+	keepExternal []string // package globs still recorded with --no-externals
+	dupDeps      bool     // if true, report packages installed in several node_modules
+	strictEnts   bool     // if true, orphaned entries are an error instead of a warning
+	nmDepth      int      // levels of installed packages to read; 0 keeps nodeModulesDepth from config
 )
 
 // entriesCmd builds a graph by first discovering roots via providers specified in config.
@@ -34,63 +44,59 @@ var entriesCmd = &cobra.Command{
 		if cfg.Root == "" {
 			cfg.Root = "." // default fallback
 		}
+		mode, err := relativeIDsMode(cfg.RelativeIDs)
+		if err != nil {
+			return err
+		}
+		cfg.RelativeIDs = mode
+		if noExternals {
+			cfg.ExcludeExternals = true
+		}
+		cfg.ExternalsAllowlist = append(cfg.ExternalsAllowlist, keepExternal...)
+		if dupDeps {
+			cfg.DuplicateDeps = true
+		}
+		if strictEnts {
+			cfg.StrictEntries = true
+		}
+		if nmDepth != 0 {
+			cfg.NodeModulesDepth = nmDepth
+		}
 		out := viper.GetString("out")
 		if out == "" && cfg.Out != "" {
 			out = cfg.Out
 		}
 
-		if verbose {
-			fmt.Fprintln(os.Stderr, "[entries] root =", cfg.Root, "out =", out)
-			fmt.Fprintln(os.Stderr, "[entries] provider specs =", len(cfg.Entries))
-		}
+		logger.Debug("entries config", "root", cfg.Root, "out", out, "providers", len(cfg.Entries))
 
-		// 2) Build providers from cfg. For now: rootsTs, explicit. Extend here as you add more types.
-		var provs []providers.Provider
+		// 2) Build providers from cfg. See buildProviders for the supported types.
 		for _, spec := range cfg.Entries {
 			switch spec.Type {
 			case "rootsTs":
-				if verbose {
-					fmt.Fprintln(os.Stderr, "[entries] add rootsTs provider file:", spec.File, "nameFrom:", spec.NameFrom)
-				}
-				provs = append(provs, providers.RootsTsProvider{
-					File:     spec.File,
-					NameFrom: spec.NameFrom, // "objectKey" | "webpackChunkName"
-				})
+				logger.Debug("add rootsTs provider", "file", spec.File, "nameFrom", spec.NameFrom)
 			case "explicit":
-				if verbose {
-					fmt.Fprintln(os.Stderr, "[entries] add explicit provider", spec.Name, "->", spec.Path)
-				}
-				provs = append(provs, providers.ExplicitProvider{
-					Name: spec.Name,
-					Path: spec.Path,
-				})
-			default:
-				return fmt.Errorf("unknown entry provider type: %s", spec.Type)
+				logger.Debug("add explicit provider", "name", spec.Name, "path", spec.Path)
 			}
 		}
+		if entriesFile != "" {
+			cfg.Entries = append(cfg.Entries, scan.EntrySpec{Type: "file", File: entriesFile})
+		}
+		provs, err := buildProviders(cfg.Entries)
+		if err != nil {
+			return err
+		}
 
 		// 3) Run providers and de-duplicate entries by absolute path.
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 
-		seen := map[string]bool{}
-		var entries []scan.Entry
-		for _, p := range provs {
-			es, err := p.Discover(ctx, cfg.Root)
-			if err != nil {
-				return err
-			}
-			for _, e := range es {
-				if !seen[e.Path] {
-					seen[e.Path] = true
-					entries = append(entries, e)
-				}
-			}
+		entries, err := discoverEntries(ctx, cfg.Root, provs, cfg.StrictEntries)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
 		}
 
-		if verbose {
-			fmt.Fprintln(os.Stderr, "[entries] discovered entries:", len(entries))
-		}
+		logger.Debug("discovered entries", "count", len(entries))
 
 		// If --print-entries is on, list them to stderr and exit early.
 		if printEntries {
@@ -105,37 +111,135 @@ var entriesCmd = &cobra.Command{
 			return fmt.Errorf("no entries discovered; check your config")
 		}
 
+		// 4b) Or one graph per entry, written next to --out as graph-<name>.<format>.
+		if perEntry {
+			return writePerEntryGraphs(ctx, cfg, entries, out)
+		}
+
 		// 4) Build graph from discovered entries (closure over reachable files only).
-		g, err := scan.BuildGraphFromEntries(ctx, cfg.Root, entries)
+		start := time.Now()
+		stopPeak := peakHeap()
+		g, manifest, err := scan.BuildGraphFromEntriesWithConfig(ctx, cfg, entries)
+		peak := stopPeak()
 		if err != nil {
 			return err
 		}
+		printSummary(os.Stderr, "entries", g, manifest, time.Since(start))
+		if memStats {
+			printPeakHeap(os.Stderr, "entries", peak)
+		}
+		if verbose {
+			printResolution(os.Stderr, "entries", manifest)
+		}
+		if cfg.DuplicateDeps {
+			printDuplicateDeps(os.Stderr, manifest)
+		}
+
+		if dropEntries {
+			g = withoutEntries(g, cfg.Root, entries)
+		}
 
 		// 5) Persist to file or stdout, same as scan.
-		var enc *json.Encoder
-		if out != "" {
-			f, err := os.Create(out)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-			enc = json.NewEncoder(f)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(g); err != nil {
-				return err
-			}
-			fmt.Fprintf(os.Stderr, "wrote %s\n", out)
-			return nil
+		if g, err = withRelativeIDs(g, cfg.Root, cfg.RelativeIDs); err != nil {
+			return err
 		}
-		enc = json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(g)
+		return writeGraph(out, g)
 	},
 }
 
+// writePerEntryGraphs builds each entry's closure separately and writes it to
+// graph-<name>.<format> in the directory of out (or the current directory).
+func writePerEntryGraphs(ctx context.Context, cfg scan.Config, entries []scan.Entry, out string) error {
+	dir := "."
+	if out != "" {
+		dir = filepath.Dir(out)
+	}
+	ext := outFormat
+	if ext == "" {
+		ext = "json"
+	}
+	used := map[string]bool{}
+	for i, e := range entries {
+		name := entryFileName(e, i)
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", entryFileName(e, i), n)
+		}
+		used[name] = true
+
+		start := time.Now()
+		stopPeak := peakHeap()
+		g, manifest, err := scan.BuildGraphFromEntriesWithConfig(ctx, cfg, []scan.Entry{e})
+		peak := stopPeak()
+		if err != nil {
+			return fmt.Errorf("entry %s: %w", e.Name, err)
+		}
+		printSummary(os.Stderr, "entries["+name+"]", g, manifest, time.Since(start))
+		if memStats {
+			printPeakHeap(os.Stderr, "entries["+name+"]", peak)
+		}
+		if dropEntries {
+			g = withoutEntries(g, cfg.Root, []scan.Entry{e})
+		}
+		if g, err = withRelativeIDs(g, cfg.Root, cfg.RelativeIDs); err != nil {
+			return err
+		}
+		if err := writeGraph(filepath.Join(dir, "graph-"+name+"."+ext), g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutEntries returns g without the entry files the build started from, and
+// without every edge to or from them; what they import stays.
+func withoutEntries(g *graph.Graph, root string, entries []scan.Entry) *graph.Graph {
+	seeds := map[string]bool{}
+	for _, e := range entries {
+		p := e.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Clean(filepath.Join(root, p))
+		}
+		seeds[p] = true
+	}
+	var keep []string
+	for _, n := range g.Nodes() {
+		if !seeds[n] {
+			keep = append(keep, n)
+		}
+	}
+	return g.Subgraph(keep)
+}
+
+// reUnsafeFileChars matches characters replaced when turning entry names into file names.
+var reUnsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// entryFileName derives a file-name-safe label from the entry name (or its path, or index).
+func entryFileName(e scan.Entry, i int) string {
+	name := e.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(e.Path), filepath.Ext(e.Path))
+	}
+	name = strings.Trim(reUnsafeFileChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		name = fmt.Sprintf("entry%d", i+1)
+	}
+	return name
+}
+
 func init() {
 	// Register subcommand and its flags.
 	rootCmd.AddCommand(entriesCmd)
+	addOutputFlags(entriesCmd)
+	addRelativeIDsFlag(entriesCmd)
 	entriesCmd.Flags().BoolVar(&printEntries, "print-entries", false, "print discovered entries and exit")
-	entriesCmd.Flags().BoolVar(&verbose, "verbose", false, "verbose logging (providers, matches, paths)")
+	entriesCmd.Flags().StringVar(&entriesFile, "entries-file", "", "also read entries from this file: one path per line, or a JSON array of {name, path}")
+	entriesCmd.Flags().BoolVar(&dropEntries, "drop-entries", false, "leave the entry files out of the output, with their edges, keeping what they depend on")
+	entriesCmd.Flags().BoolVar(&perEntry, "per-entry", false, "write one graph per entry (graph-<name>.<format>, next to --out) instead of a merged graph")
+	entriesCmd.Flags().BoolVar(&noExternals, "no-externals", false, "don't record pkg: externals (same as excludeExternals in config)")
+	entriesCmd.Flags().BoolVar(&memStats, "mem-stats", false, "print the peak heap of the build after the summary")
+	entriesCmd.Flags().BoolVar(&dupDeps, "duplicate-deps", false, "report packages imported from more than one node_modules copy (also duplicateDeps in config)")
+	entriesCmd.Flags().IntVar(&nmDepth, "include-node-modules-depth", 0, "record package-to-package edges by reading imported packages in node_modules, this many levels deep (also nodeModulesDepth in config)")
+	entriesCmd.Flags().StringSliceVar(&keepExternal, "keep-external", nil, "package glob to keep despite --no-externals (repeatable, e.g. react,@acme/*)")
+	entriesCmd.Flags().BoolVar(&strictEnts, "strict-entries", false, "fail when an entry's file is missing or not a source file, instead of warning and skipping it (also strictEntries in config)")
+	entriesCmd.Flags().BoolVar(&verbose, "verbose", false, "print how specifiers were resolved (relative, alias, baseUrl, bare, ...)")
 }
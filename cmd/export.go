@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	_ "modernc.org/sqlite" // pure-Go driver, so no second cgo dependency next to tree-sitter
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+var (
+	exportGraph    string
+	exportFormat   string
+	exportOut      string
+	exportReduce   bool // if true, drop edges implied by longer paths before writing
+	exportCondense bool // if true, collapse each import cycle into one node
+	exportInvert   bool // if true, write edges as "depended on by" instead of "imports"
+)
+
+// exportCmd converts a saved graph.json to another format, including a SQLite
+// database for ad-hoc SQL queries.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Convert a graph.json to sqlite, yaml, toml, ndjson, or dot",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		g, err := loadGraph(exportGraph)
+		if err != nil {
+			return err
+		}
+		if exportCondense {
+			g, _ = g.Condensation()
+		}
+		if exportReduce {
+			g = g.TransitiveReduction()
+		}
+		if exportInvert {
+			g = g.Inverted()
+		}
+		if exportFormat != "sqlite" {
+			outFormat = exportFormat
+			return writeGraph(exportOut, g)
+		}
+		if exportOut == "" {
+			return fmt.Errorf("--out is required for --format sqlite")
+		}
+		cmd.SilenceUsage = true
+		if err := writeSQLite(exportOut, g); err != nil {
+			return err
+		}
+		logger.Info("wrote database", "path", exportOut)
+		return nil
+	},
+}
+
+// sqliteSchema is the layout written by writeSQLite. Node ids are the node names
+// (file paths or "pkg:<name>"), so queries can use them directly.
+const sqliteSchema = `
+CREATE TABLE nodes (
+	id   TEXT PRIMARY KEY,
+	kind TEXT NOT NULL
+);
+CREATE TABLE edges (
+	from_id TEXT NOT NULL REFERENCES nodes(id),
+	to_id   TEXT NOT NULL REFERENCES nodes(id),
+	weight  INTEGER NOT NULL, -- number of import specifiers behind the edge (at least 1)
+	kind    TEXT NOT NULL DEFAULT '', -- edge label, e.g. rendered/imported in component graphs
+	PRIMARY KEY (from_id, to_id)
+);
+CREATE INDEX edges_to ON edges(to_id);
+`
+
+// writeSQLite writes g to a new SQLite database at path, replacing any existing file.
+func writeSQLite(path string, g *graph.Graph) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	nodeStmt, err := tx.Prepare(`INSERT INTO nodes (id, kind) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	for n, kind := range g.NodeKinds() {
+		if _, err := nodeStmt.Exec(n, kind); err != nil {
+			return fmt.Errorf("insert node %s: %w", n, err)
+		}
+	}
+	edgeStmt, err := tx.Prepare(`INSERT INTO edges (from_id, to_id, weight, kind) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	var edgeErr error
+	g.ForEachEdge(func(from, to string) {
+		if edgeErr != nil {
+			return
+		}
+		weight := max(len(g.Specs(from, to)), 1)
+		if _, err := edgeStmt.Exec(from, to, weight, g.EdgeKind(from, to)); err != nil {
+			edgeErr = fmt.Errorf("insert edge %s -> %s: %w", from, to, err)
+		}
+	})
+	if edgeErr != nil {
+		return edgeErr
+	}
+	return tx.Commit()
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&exportGraph, "graph", "", "path to graph.json to export")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "sqlite", "output format: sqlite|json|yaml|toml|ndjson|dot")
+	exportCmd.Flags().BoolVar(&exportCondense, "condense", false, "collapse each import cycle (strongly connected component) into one scc:<first node> node, leaving a DAG")
+	exportCmd.Flags().BoolVar(&exportReduce, "reduce", false, "drop edges implied by longer paths (transitive reduction) to declutter visualizations")
+	exportCmd.Flags().BoolVar(&exportInvert, "invert", false, "reverse every edge, so B -> A reads \"B is depended on by A\" instead of \"A imports B\"")
+	exportCmd.Flags().StringVar(&exportOut, "out", "", "output path (required for sqlite; stdout otherwise)")
+	addCompactFlag(exportCmd)
+}
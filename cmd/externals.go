@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var (
+	externalsGraph    string
+	externalsManifest bool // if true, print the deduplicated package names instead of usage counts
+	externalsJSON     bool // with --manifest, print the names as a JSON array
+)
+
+// externalsCmd lists third-party packages by how many internal files import them.
+var externalsCmd = &cobra.Command{
+	Use:   "externals",
+	Short: "List external packages by number of importing files",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if externalsGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		if externalsJSON && !externalsManifest {
+			return fmt.Errorf("--json needs --manifest")
+		}
+		g, err := loadGraph(externalsGraph)
+		if err != nil {
+			return err
+		}
+		if externalsManifest {
+			names := externalPackages(g.Nodes())
+			if externalsJSON {
+				return newJSONEncoder(os.Stdout).Encode(names)
+			}
+			for _, n := range names {
+				fmt.Println(n)
+			}
+			return nil
+		}
+		usage := g.ExternalUsage()
+		pkgs := make([]string, 0, len(usage))
+		for p := range usage {
+			pkgs = append(pkgs, p)
+		}
+		// most-used first; ties by name
+		sort.Slice(pkgs, func(i, j int) bool {
+			if usage[pkgs[i]] != usage[pkgs[j]] {
+				return usage[pkgs[i]] > usage[pkgs[j]]
+			}
+			return pkgs[i] < pkgs[j]
+		})
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range pkgs {
+			fmt.Fprintf(tw, "%d\t%s\n", usage[p], strings.TrimPrefix(p, "pkg:"))
+		}
+		return tw.Flush()
+	},
+}
+
+// externalPackages returns the packages behind the pkg: nodes among nodes,
+// subpaths stripped ("pkg:@acme/ui/button" -> "@acme/ui"), sorted and
+// deduplicated.
+func externalPackages(nodes []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, n := range nodes {
+		spec, ok := strings.CutPrefix(n, "pkg:")
+		if !ok {
+			continue
+		}
+		name := scan.PackageName(spec)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	rootCmd.AddCommand(externalsCmd)
+	externalsCmd.Flags().StringVar(&externalsGraph, "graph", "", "path to graph.json to analyze")
+	externalsCmd.Flags().BoolVar(&externalsManifest, "manifest", false, "print each package imported (subpaths stripped) once, sorted, instead of usage counts")
+	externalsCmd.Flags().BoolVar(&externalsJSON, "json", false, "with --manifest, print the package names as a JSON array")
+	addCompactFlag(externalsCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	focusGraph  string
+	focusNode   string
+	focusRadius int
+)
+
+// focusCmd writes the ego graph of one node: everything within --radius hops of it.
+var focusCmd = &cobra.Command{
+	Use:   "focus",
+	Short: "Write the subgraph within --radius hops of a node (importers and imports) from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if focusGraph == "" || focusNode == "" {
+			return fmt.Errorf("--graph and --node are required")
+		}
+		if focusRadius < 0 {
+			return fmt.Errorf("--radius must be >= 0")
+		}
+		g, err := loadGraph(focusGraph)
+		if err != nil {
+			return err
+		}
+		node, err := resolveNodeArg(viper.GetString("root"), g, focusNode, focusGraph)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		return writeGraph(viper.GetString("out"), g.EgoGraph(node, focusRadius))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(focusCmd)
+	addOutputFlags(focusCmd)
+	focusCmd.Flags().StringVar(&focusGraph, "graph", "", "path to graph.json to extract from")
+	focusCmd.Flags().StringVar(&focusNode, "node", "", "node to focus on (file path as in the graph, or relative to --root)")
+	focusCmd.Flags().IntVar(&focusRadius, "radius", 1, "hops to include in either direction")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// outFormat selects the encoding used by writeGraph (shared by graph-emitting commands).
+var outFormat string
+
+// outCompact drops the indentation from JSON output.
+var outCompact bool
+
+// addOutputFlags registers the output flags shared by commands that emit a graph.
+func addOutputFlags(c *cobra.Command) {
+	c.Flags().StringVar(&outFormat, "format", "json", "output format: json|yaml|toml|ndjson|dot")
+	addCompactFlag(c)
+}
+
+// addCompactFlag registers --compact on c.
+func addCompactFlag(c *cobra.Command) {
+	c.Flags().BoolVar(&outCompact, "compact", false, "write JSON without indentation (smaller, faster to parse)")
+}
+
+// newJSONEncoder returns a JSON encoder for w, indented unless --compact is set.
+func newJSONEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if !outCompact {
+		enc.SetIndent("", "  ")
+	}
+	return enc
+}
+
+// loadGraph reads a graph.json written by scan/entries/components.
+func loadGraph(path string) (*graph.Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open --graph: %w", err)
+	}
+	defer f.Close()
+	g := graph.New()
+	if err := json.NewDecoder(f).Decode(g); err != nil {
+		return nil, fmt.Errorf("decode graph: %w", err)
+	}
+	return g, nil
+}
+
+// writeGraph writes g to out (or stdout when out is empty) in the selected --format.
+func writeGraph(out string, g *graph.Graph) error {
+	if out == "" {
+		return encodeGraph(os.Stdout, g)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := encodeGraph(f, g); err != nil {
+		return err
+	}
+	logger.Info("wrote graph", "path", out)
+	return nil
+}
+
+// replaceFile writes path through write into a temporary file beside it and
+// renames that over path, so readers see either the old contents or the new ones,
+// never a partial write.
+func replaceFile(path string, write func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func encodeGraph(w io.Writer, g *graph.Graph) error {
+	switch outFormat {
+	case "", "json":
+		return newJSONEncoder(w).Encode(g)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(g.Document()); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(g.Document())
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, e := range g.Document().Edges {
+			specs := e.Specs
+			if len(specs) == 0 {
+				specs = []string{""}
+			}
+			for _, spec := range specs {
+				if err := enc.Encode(ndjsonEdge{From: e.From, To: e.To, Spec: spec, Kind: e.Kind}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case "dot":
+		return g.WriteDOT(w)
+	default:
+		return fmt.Errorf("unknown --format %q (want json, yaml, toml, ndjson, or dot)", outFormat)
+	}
+}
+
+// ndjsonEdge is one line of --format ndjson output.
+type ndjsonEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Spec string `json:"spec,omitempty"`
+	Kind string `json:"kind,omitempty"` // graph edge kind, e.g. "approximate"
+}
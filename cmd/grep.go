@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	grepGraph         string
+	grepNeighbors     int  // show up to this many importers and imports per match
+	grepWithNeighbors bool // if true, write the subgraph of matches and their neighbors
+	grepJSON          bool // if true, print [{node, in, out, ...}] as JSON
+)
+
+// grepMatch is one node matching the pattern, with its degree and, when asked
+// for, a sample of its neighbors.
+type grepMatch struct {
+	Node      string   `json:"node"`
+	In        int      `json:"in"`
+	Out       int      `json:"out"`
+	Importers []string `json:"importers,omitempty"`
+	Imports   []string `json:"imports,omitempty"`
+}
+
+// grepCmd finds nodes by regular expression, for questions globs answer badly,
+// like every config file: philtographer grep --graph g.json '\.config\.(ts|js)$'.
+var grepCmd = &cobra.Command{
+	Use:   "grep <regex>",
+	Short: "Print nodes of a graph.json matching a regular expression, with their degree",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if grepGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		if grepWithNeighbors && grepJSON {
+			return fmt.Errorf("--with-neighbors writes a graph; it can't be combined with --json")
+		}
+		g, err := loadGraph(grepGraph)
+		if err != nil {
+			return err
+		}
+
+		var matches []string
+		for _, n := range g.Nodes() {
+			if re.MatchString(n) {
+				matches = append(matches, n)
+			}
+		}
+		sort.Strings(matches)
+
+		if grepWithNeighbors {
+			keep := map[string]bool{}
+			for _, n := range matches {
+				keep[n] = true
+				for _, m := range g.InNeighbors(n) {
+					keep[m] = true
+				}
+				for _, m := range g.OutNeighbors(n) {
+					keep[m] = true
+				}
+			}
+			nodes := make([]string, 0, len(keep))
+			for n := range keep {
+				nodes = append(nodes, n)
+			}
+			return writeGraph(viper.GetString("out"), g.Subgraph(nodes))
+		}
+
+		out := make([]grepMatch, 0, len(matches))
+		for _, n := range matches {
+			in, outDeg := g.Degrees(n)
+			m := grepMatch{Node: n, In: in, Out: outDeg}
+			if grepNeighbors > 0 {
+				m.Importers = sampleNodes(g.InNeighbors(n), grepNeighbors)
+				m.Imports = sampleNodes(g.OutNeighbors(n), grepNeighbors)
+			}
+			out = append(out, m)
+		}
+		if grepJSON {
+			return newJSONEncoder(os.Stdout).Encode(out)
+		}
+		if grepNeighbors > 0 {
+			// one block per match; neighbor lines would break tabwriter's columns
+			for _, m := range out {
+				fmt.Printf("%s  in=%d out=%d\n", m.Node, m.In, m.Out)
+				if len(m.Importers) > 0 {
+					fmt.Printf("  <- %s\n", joinSample(m.Importers, m.In))
+				}
+				if len(m.Imports) > 0 {
+					fmt.Printf("  -> %s\n", joinSample(m.Imports, m.Out))
+				}
+			}
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, m := range out {
+			fmt.Fprintf(tw, "%s\tin=%d\tout=%d\n", m.Node, m.In, m.Out)
+		}
+		return tw.Flush()
+	},
+}
+
+// sampleNodes returns the first n of nodes in sorted order.
+func sampleNodes(nodes []string, n int) []string {
+	sort.Strings(nodes)
+	if len(nodes) > n {
+		nodes = nodes[:n]
+	}
+	return nodes
+}
+
+// joinSample joins a neighbor sample, noting how many of total were left out.
+func joinSample(sample []string, total int) string {
+	s := strings.Join(sample, ", ")
+	if total > len(sample) {
+		s += fmt.Sprintf(", … %d more", total-len(sample))
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+	addOutputFlags(grepCmd)
+	grepCmd.Flags().StringVar(&grepGraph, "graph", "", "path to graph.json to search")
+	grepCmd.Flags().IntVar(&grepNeighbors, "neighbors", 0, "also list up to this many importers and imports of each match")
+	grepCmd.Flags().BoolVar(&grepWithNeighbors, "with-neighbors", false, "write the subgraph of matches and their direct neighbors (to --out, in --format) instead of listing")
+	grepCmd.Flags().BoolVar(&grepJSON, "json", false, "print [{node, in, out, importers, imports}] as JSON")
+}
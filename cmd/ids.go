@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// relativeIDs is --relative-ids, shared by the graph-building commands: "root" or
+// "vcs", overriding relativeIds from config.
+var relativeIDs string
+
+// addRelativeIDsFlag registers --relative-ids on c.
+func addRelativeIDsFlag(c *cobra.Command) {
+	c.Flags().StringVar(&relativeIDs, "relative-ids", "", "write file nodes as forward-slashed paths relative to the git checkout (vcs) or --root (root), so graphs compare across machines: vcs|root")
+	c.Flags().Lookup("relative-ids").NoOptDefVal = "vcs"
+}
+
+// relativeIDsMode returns the --relative-ids mode, falling back to configured.
+func relativeIDsMode(configured string) (string, error) {
+	mode := configured
+	if relativeIDs != "" {
+		mode = relativeIDs
+	}
+	switch mode {
+	case "", "root", "vcs":
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --relative-ids %q (want vcs or root)", mode)
+}
+
+// withRelativeIDs returns g with file nodes renamed to forward-slashed paths
+// relative to root ("root" mode) or to the git checkout containing root ("vcs"
+// mode, root itself outside one). "pkg:" externals keep their names, and mode ""
+// returns g as is.
+func withRelativeIDs(g *graph.Graph, root, mode string) (*graph.Graph, error) {
+	rename, err := relativeIDFunc(root, mode)
+	if err != nil || rename == nil {
+		return g, err
+	}
+	return g.Relabel(rename), nil
+}
+
+// relativeIDFunc returns the node renaming withRelativeIDs applies, or nil for
+// mode "". Streamed output uses it edge by edge.
+func relativeIDFunc(root, mode string) (func(string) string, error) {
+	if mode == "" {
+		return nil, nil
+	}
+	base, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if mode == "vcs" {
+		base = vcsRoot(base)
+	}
+	// nodes are recorded with symlinks resolved; so should the base be
+	realBase := base
+	if r, err := filepath.EvalSymlinks(base); err == nil {
+		realBase = r
+	}
+	return func(n string) string {
+		if graph.KindOf(n) == graph.KindExternal {
+			return n
+		}
+		abs := n
+		if !filepath.IsAbs(abs) {
+			// graphs scanned with a relative --root record paths relative to the
+			// working directory
+			if a, err := filepath.Abs(n); err == nil {
+				abs = a
+			}
+		}
+		rel, err := filepath.Rel(base, abs)
+		if err != nil {
+			return n
+		}
+		if r, err := filepath.Rel(realBase, abs); err == nil && strings.HasPrefix(rel, "..") && !strings.HasPrefix(r, "..") {
+			rel = r
+		}
+		return filepath.ToSlash(rel)
+	}, nil
+}
+
+// vcsRoot returns the nearest directory at or above dir containing .git, or dir.
+func vcsRoot(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if d == filepath.Dir(d) {
+			return dir
+		}
+	}
+}
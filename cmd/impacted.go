@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/philjestin/philtographer/internal/graph"
+	"github.com/philjestin/philtographer/internal/scan"
+	"github.com/philjestin/philtographer/internal/scan/providers"
+)
+
+var (
+	impGraph       string
+	impChanged     []string
+	impTransparent bool
+	impGitBase     string
+	impAffected    bool // if true, write the changed+impacted subgraph instead of listing paths
+)
+
+// impactedCmd prints the union of reverse transitive dependents for a set of changed files or globs.
+var impactedCmd = &cobra.Command{
+	Use:   "impacted",
+	Short: "Print files impacted by changes (paths or globs) using a graph.json file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if impGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		if len(impChanged) == 0 && impGitBase == "" {
+			return fmt.Errorf("--changed or --changed-from-git is required (file paths or globs like 'src/shared/**')")
+		}
+		g, err := loadGraph(impGraph)
+		if err != nil {
+			return err
+		}
+		root := viper.GetString("root")
+		if root == "" {
+			root = "."
+		}
+		changed := impChanged
+		if impGitBase != "" {
+			cmd.SilenceUsage = true
+			files, err := providers.GitChanged(cmd.Context(), root, impGitBase)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, gitNodes(g, files)...)
+		}
+
+		impacted := g.Impacted
+		if impTransparent {
+			impacted = func(n string) []string { return scan.ImpactedThroughBarrels(g, n) }
+		}
+		seen := map[string]bool{}
+		nodes := changedNodes(root, g, changed)
+		for _, n := range nodes {
+			for _, imp := range impacted(n) {
+				seen[imp] = true
+			}
+		}
+		// A graph artifact of just the affected area, e.g. to attach to a PR.
+		if impAffected {
+			for n := range seen {
+				nodes = append(nodes, n)
+			}
+			return writeGraph(viper.GetString("out"), g.Subgraph(nodes))
+		}
+		out := make([]string, 0, len(seen))
+		for n := range seen {
+			out = append(out, n)
+		}
+		sort.Strings(out)
+		for _, n := range out {
+			fmt.Println(n)
+		}
+		return nil
+	},
+}
+
+// gitNodes maps absolute paths from git onto g's node keys: graphs scanned with a
+// relative --root record paths relative to the working directory instead.
+func gitNodes(g *graph.Graph, files []string) []string {
+	wd, _ := os.Getwd()
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if rel, err := filepath.Rel(wd, f); err == nil && g.Has(rel) {
+			f = rel
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func init() {
+	rootCmd.AddCommand(impactedCmd)
+	impactedCmd.Flags().StringVar(&impGraph, "graph", "", "path to graph.json to analyze")
+	impactedCmd.Flags().StringSliceVar(&impChanged, "changed", nil, "changed files or globs (repeatable or comma-separated)")
+	impactedCmd.Flags().StringVar(&impGitBase, "changed-from-git", "", "take changed files from git: everything changed since diverging from this ref, uncommitted included")
+	impactedCmd.Flags().BoolVar(&impAffected, "affected-only", false, "write the subgraph of changed and impacted files (to --out, in --format) instead of listing paths")
+	addOutputFlags(impactedCmd)
+	impactedCmd.Flags().BoolVar(&impTransparent, "transparent-barrels", false, "follow pure re-export barrels per symbol instead of impacting every importer")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var importsFile string
+
+// importsCmd parses one file and resolves each of its imports, for checking why an
+// import didn't become an edge without building a graph.
+var importsCmd = &cobra.Command{
+	Use:   "imports",
+	Short: "Print each import specifier of a file and what it resolves to (or why it doesn't)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importsFile == "" {
+			return fmt.Errorf("--file is required")
+		}
+		cmd.SilenceUsage = true
+		root := viper.GetString("root")
+		if root == "" {
+			root = "."
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		file := importsFile
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		r, err := newResolver(root)
+		if err != nil {
+			return err
+		}
+		specs := scan.FileImports(file, data)
+		sort.Strings(specs)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, spec := range specs {
+			if scan.IsApproximate(spec) {
+				fmt.Fprintf(tw, "%s\t-> approximately %s\n", spec, strings.Join(scan.ExpandApproximate(file, spec), ", "))
+				continue
+			}
+			to, err := r.Resolve(file, spec)
+			if err != nil {
+				fmt.Fprintf(tw, "%s\t-> error: %v\n", spec, err)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t-> %s\n", spec, to)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importsCmd)
+	importsCmd.Flags().StringVar(&importsFile, "file", "", "file whose imports to resolve")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var (
+	styleGraph     string
+	styleExternals bool // if true, also report packages imported both as default and by name
+)
+
+// importStyleCmd reports inconsistent imports: a module imported in several
+// statements by one file, or as a default by some files and by name by others.
+var importStyleCmd = &cobra.Command{
+	Use:   "import-style",
+	Short: "Report duplicate imports and modules imported both as default and by name, from a graph.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if styleGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		g, err := loadGraph(styleGraph)
+		if err != nil {
+			return err
+		}
+		report := scan.CheckImportStyle(g)
+		fmt.Printf("duplicate imports (%d):\n", len(report.Duplicates))
+		for _, d := range report.Duplicates {
+			fmt.Printf("  %s imports %q in %d statements\n", d.File, d.Spec, d.Count)
+		}
+		var mixed []scan.MixedImport
+		for _, m := range report.Mixed {
+			if styleExternals || !strings.HasPrefix(m.Module, "pkg:") {
+				mixed = append(mixed, m)
+			}
+		}
+		fmt.Printf("mixed default/named imports (%d):\n", len(mixed))
+		for _, m := range mixed {
+			fmt.Printf("  %s\n", m.Module)
+			fmt.Printf("    default (%d): %s\n", len(m.Default), strings.Join(m.Default, ", "))
+			fmt.Printf("    named only (%d): %s\n", len(m.Named), strings.Join(m.Named, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importStyleCmd)
+	importStyleCmd.Flags().StringVar(&styleGraph, "graph", "", "path to graph.json to analyze (scan or entries output, with edge specs)")
+	importStyleCmd.Flags().BoolVar(&styleExternals, "externals", false, "also report pkg: externals imported both ways (noisy for packages like react)")
+}
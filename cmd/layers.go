@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var (
+	layersGraph string
+	layersJSON  bool // if true, print the matrix as JSON instead of a table
+)
+
+// layersCmd aggregates a graph's edges into a layer-to-layer matrix using the
+// "layers" rules from config, for a one-page view of cross-layer coupling.
+var layersCmd = &cobra.Command{
+	Use:   "layers",
+	Short: "Count edges between the architectural layers configured in \"layers\"",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if layersGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		var cfg scan.Config
+		if err := viper.Unmarshal(&cfg); err != nil {
+			return fmt.Errorf("config unmarshal: %w", err)
+		}
+		if len(cfg.Layers) == 0 {
+			return fmt.Errorf("no layers configured; add \"layers\": [{\"pattern\": \"src/ui/**\", \"layer\": \"ui\"}, ...] to your config")
+		}
+		if cfg.Root == "" {
+			cfg.Root = "."
+		}
+		g, err := loadGraph(layersGraph)
+		if err != nil {
+			return err
+		}
+		m := g.LayerMatrix(cfg.LayerNames(), cfg.LayerOf)
+		if layersJSON {
+			return newJSONEncoder(os.Stdout).Encode(m)
+		}
+		// rows import from columns; the diagonal is edges within a layer
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(tw, "from \\ to\t")
+		for _, l := range m.Layers {
+			fmt.Fprintf(tw, "%s\t", l)
+		}
+		fmt.Fprintln(tw)
+		for i, l := range m.Layers {
+			fmt.Fprintf(tw, "%s\t", l)
+			for _, n := range m.Counts[i] {
+				fmt.Fprintf(tw, "%d\t", n)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(layersCmd)
+	layersCmd.Flags().StringVar(&layersGraph, "graph", "", "path to graph.json to analyze")
+	layersCmd.Flags().BoolVar(&layersJSON, "json", false, "print the matrix as JSON ({layers, counts})")
+	addCompactFlag(layersCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	logLevel string // debug|info|warn|error
+	jsonLogs bool   // if true, write logs as JSON lines (for CI)
+)
+
+// logger carries diagnostics to stderr. Reports a command exists to print (graph
+// JSON, summaries, lists) are written directly instead.
+var logger = slog.New(newConsoleHandler(os.Stderr, slog.LevelInfo, false))
+
+// setupLogging replaces logger according to --log-level and --json-logs.
+func setupLogging(w *os.File) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("invalid --log-level %q (want debug, info, warn, or error)", logLevel)
+	}
+	if jsonLogs {
+		logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+		return nil
+	}
+	logger = slog.New(newConsoleHandler(w, level, useColor(w)))
+	return nil
+}
+
+// useColor reports whether w is a terminal and NO_COLOR is unset.
+func useColor(w *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := w.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleHandler writes records as "LEVEL message key=value ...", one per line,
+// with the level colored when color is set. Times are left out: these are
+// interactive runs, and --json-logs has them.
+type consoleHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	color bool
+	attrs []slog.Attr // from WithAttrs, keys already prefixed
+	group string      // prefix for attribute keys, from WithGroup
+}
+
+func newConsoleHandler(w io.Writer, level slog.Level, color bool) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level, color: color}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, l slog.Level) bool { return l >= h.level }
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	tag, color := "DEBUG", "\x1b[90m"
+	switch {
+	case r.Level >= slog.LevelError:
+		tag, color = "ERROR", "\x1b[31m"
+	case r.Level >= slog.LevelWarn:
+		tag, color = "WARN ", "\x1b[33m"
+	case r.Level >= slog.LevelInfo:
+		tag, color = "INFO ", "\x1b[36m"
+	}
+	if h.color {
+		b.WriteString(color + tag + "\x1b[0m")
+	} else {
+		b.WriteString(tag)
+	}
+	b.WriteString(" " + r.Message)
+	write := func(a slog.Attr) {
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		v := a.Value.Resolve().String()
+		if strings.ContainsAny(v, " \t\n\"=") || v == "" {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", a.Key, v)
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.group + a.Key
+		write(a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		a.Key = h.group + a.Key
+		c.attrs = append(c.attrs, a)
+	}
+	return &c
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.group = h.group + name + "."
+	return &c
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+var memStats bool // if true, print the peak heap of the build
+
+// heapMetric is the memory occupied by live and not-yet-swept heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// peakHeap samples the heap every 10ms until stop is called and returns the
+// highest value seen. Sampling is cheap (no stop-the-world), but short spikes
+// between samples can be missed.
+func peakHeap() (stop func() uint64) {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	var peak uint64
+	read := func() {
+		metrics.Read(sample)
+		if v := sample[0].Value.Uint64(); v > peak {
+			peak = v
+		}
+	}
+	read()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(10 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				read()
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		wg.Wait()
+		read()
+		return peak
+	}
+}
+
+// printPeakHeap prints the line --mem-stats adds after a build's summary.
+func printPeakHeap(w io.Writer, label string, peak uint64) {
+	fmt.Fprintf(w, "%s: peak-heap=%.1fMiB\n", label, float64(peak)/(1<<20))
+}
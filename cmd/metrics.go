@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var metricsGraph string
+
+// metricsCmd prints graph health metrics as JSON, for dashboards that trend them
+// across commits.
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print node/edge counts, density, fan-in/fan-out, and cycle counts of a graph.json as JSON",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metricsGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		g, err := loadGraph(metricsGraph)
+		if err != nil {
+			return err
+		}
+		return newJSONEncoder(os.Stdout).Encode(g.Metrics())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.Flags().StringVar(&metricsGraph, "graph", "", "path to graph.json to measure")
+	addCompactFlag(metricsCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/tsgraph"
+)
+
+var parseFile string
+
+// parseCmd prints what the component parser extracts from one file, for working
+// out why a JSX usage didn't become an edge in the component graph (say, the tag
+// isn't in the import map).
+var parseCmd = &cobra.Command{
+	Use:   "parse",
+	Short: "Print the components, imports, and JSX usages the parser sees in a file, as JSON",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if parseFile == "" {
+			return fmt.Errorf("--file is required (a .ts or .tsx file)")
+		}
+		cmd.SilenceUsage = true
+		data, err := os.ReadFile(parseFile)
+		if err != nil {
+			return err
+		}
+		info, err := tsgraph.ParseTSFile(parseFile, data)
+		if err != nil {
+			return err
+		}
+		return newJSONEncoder(os.Stdout).Encode(info)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parseCmd)
+	parseCmd.Flags().StringVar(&parseFile, "file", "", "file to parse")
+	addCompactFlag(parseCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/scan"
+	"github.com/philjestin/philtographer/internal/scan/providers"
+)
+
+// buildProviders maps config entry specs to providers. Extend here as you add more types.
+func buildProviders(specs []scan.EntrySpec) ([]providers.Provider, error) {
+	var provs []providers.Provider
+	for _, spec := range specs {
+		switch spec.Type {
+		case "rootsTs":
+			provs = append(provs, providers.RootsTsProvider{
+				File:     spec.File,
+				NameFrom: spec.NameFrom, // "objectKey" | "webpackChunkName"
+				Style:    spec.Style,
+				Regex:    spec.Regex,
+			})
+		case "explicit":
+			provs = append(provs, providers.ExplicitProvider{
+				Name: spec.Name,
+				Path: spec.Path,
+			})
+		case "file":
+			provs = append(provs, providers.FileProvider{File: spec.File})
+		case "glob":
+			provs = append(provs, providers.GlobProvider{
+				Pattern:  spec.Pattern,
+				NameFrom: spec.NameFrom, // "webpackChunkName" | "path"
+			})
+		default:
+			return nil, fmt.Errorf("unknown entry provider type: %s", spec.Type)
+		}
+	}
+	return provs, nil
+}
+
+// discoverEntries runs providers against root and de-duplicates entries by path.
+// Orphaned entries (see providers.CheckEntry) are logged and dropped, or fail
+// discovery when strict is set.
+func discoverEntries(ctx context.Context, root string, provs []providers.Provider, strict bool) ([]scan.Entry, error) {
+	seen := map[string]bool{}
+	var entries []scan.Entry
+	for _, p := range provs {
+		es, err := p.Discover(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range es {
+			if !seen[e.Path] {
+				seen[e.Path] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+	return checkEntries(root, entries, strict)
+}
+
+// checkEntries drops entries whose file is missing or not a source file, warning
+// about each one. With strict, any orphan is an error instead.
+func checkEntries(root string, entries []scan.Entry, strict bool) ([]scan.Entry, error) {
+	kept := entries[:0]
+	var orphans []string
+	for _, e := range entries {
+		if err := providers.CheckEntry(root, e); err != nil {
+			name := e.Name
+			if name == "" {
+				name = e.Path
+			}
+			logger.Warn("orphaned entry", "name", name, "err", err)
+			orphans = append(orphans, name)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if strict && len(orphans) > 0 {
+		return nil, fmt.Errorf("%d orphaned entries (%s); fix or remove them in config", len(orphans), strings.Join(orphans, ", "))
+	}
+	return kept, nil
+}
+
+// entryPaths returns just the paths of entries.
+func entryPaths(entries []scan.Entry) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Path)
+	}
+	return out
+}
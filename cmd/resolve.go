@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+var (
+	resolveFrom string
+	resolveSpec string
+)
+
+// resolveCmd runs a single specifier through the tsconfig-aware resolver, for debugging alias setups.
+var resolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve one import specifier from a file and print the result (or what was tried)",
+	// A failed resolution is the expected output here, not a usage mistake.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if resolveFrom == "" || resolveSpec == "" {
+			return fmt.Errorf("--from and --spec are required")
+		}
+		root := viper.GetString("root")
+		if root == "" {
+			root = "."
+		}
+		from := resolveFrom
+		if !filepath.IsAbs(from) {
+			if abs, err := filepath.Abs(from); err == nil {
+				from = abs
+			}
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+
+		r, err := newResolver(root)
+		if err != nil {
+			return err
+		}
+		to, err := r.Resolve(from, resolveSpec)
+		if err != nil {
+			return err
+		}
+		fmt.Println(to)
+		return nil
+	},
+}
+
+// newResolver returns a resolver for root configured like the graph builders':
+// extensions, workspaces, the import map, and Yarn PnP from config. Ambiguous index files
+// are logged as warnings.
+func newResolver(root string) (*scan.Resolver, error) {
+	r := scan.NewResolver(root)
+	r.Extensions = viper.GetStringSlice("extensions")
+	workspaces := viper.GetStringSlice("workspaces")
+	if len(workspaces) == 0 && viper.GetBool("autoWorkspaces") {
+		workspaces = scan.DetectWorkspaces(root)
+	}
+	r.Workspaces = scan.FindWorkspaces(root, workspaces)
+	r.BaseDirs = viper.GetStringSlice("baseDirs")
+	if path := viper.GetString("importMap"); path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		im, err := scan.LoadImportMap(root, path)
+		if err != nil {
+			return nil, err
+		}
+		r.ImportMap = im
+	}
+	if viper.GetBool("pnp") {
+		pnp, err := scan.LoadPnP(root)
+		if err != nil {
+			return nil, err
+		}
+		r.PnP = pnp
+	}
+	r.OnAmbiguousIndex = warnAmbiguousIndex
+	return r, nil
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+	resolveCmd.Flags().StringVar(&resolveFrom, "from", "", "file containing the import")
+	resolveCmd.Flags().StringVar(&resolveSpec, "spec", "", "import specifier to resolve (e.g. @app/foo, ./bar)")
+}
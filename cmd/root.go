@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -21,6 +20,9 @@ var rootCmd = &cobra.Command{
 	Short: "Code graph & impact analysis for monorepos",
 	// PersistentPreRunE executes before any subcommand; we use it to load config/env.
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(os.Stderr); err != nil {
+			return err
+		}
 		// If --config was provided, take it; else look for ./philtographer.config.{json,yaml,toml}
 		if cfgFile != "" {
 			viper.SetConfigFile(cfgFile)
@@ -36,7 +38,11 @@ var rootCmd = &cobra.Command{
 
 		// Read config file if present; it's ok if none is found.
 		if err := viper.ReadInConfig(); err == nil {
-			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+			logger.Info("using config file", "path", viper.ConfigFileUsed())
+			// Pull in any base config named by "extends".
+			if err := applyExtends(); err != nil {
+				return err
+			}
 		}
 		return nil
 	},
@@ -45,7 +51,7 @@ var rootCmd = &cobra.Command{
 // Execute is called from main.go and starts the CLI.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
 }
@@ -55,8 +61,14 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ./philtographer.config.{json,yaml,toml})")
 	rootCmd.PersistentFlags().StringVar(&workspace, "root", ".", "repo root to scan")
 	rootCmd.PersistentFlags().StringVar(&outputFile, "out", "", "write graph JSON to file")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "minimum log level: debug|info|warn|error")
+	rootCmd.PersistentFlags().BoolVar(&jsonLogs, "json-logs", false, "write logs to stderr as JSON lines (for CI)")
 
 	// Bind these flags to viper keys so config/env/flags merge cleanly.
 	_ = viper.BindPFlag("root", rootCmd.PersistentFlags().Lookup("root"))
 	_ = viper.BindPFlag("out", rootCmd.PersistentFlags().Lookup("out"))
+
+	// Workspaces declared in the root package.json / pnpm-workspace.yaml resolve
+	// without any config; "autoWorkspaces": false turns that off.
+	viper.SetDefault("autoWorkspaces", true)
 }
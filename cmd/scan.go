@@ -1,60 +1,378 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/philjestin/philtographer/internal/graph"
 	"github.com/philjestin/philtographer/internal/scan"
 )
 
+var (
+	scanFromEntries  bool     // if true, prune the full graph to what's reachable from configured entries
+	scanFailOnCycles bool     // if true, exit non-zero when internal files form an import cycle
+	scanConfineRoot  string   // "warn" or "fail": report imports resolving outside --root
+	scanNoExternals  bool     // if true, don't record pkg: externals at all
+	scanKeepExternal []string // package globs still recorded with --no-externals
+	scanVerbose      bool     // if true, print a breakdown of how specifiers were resolved
+	scanDupDeps      bool     // if true, report packages installed in several node_modules
+	scanMaxFiles     int      // source file limit for the walk; 0 keeps maxFiles from config
+	scanOnly         string   // walk only this subdirectory of --root, marking imports leaving it as boundary nodes
+	scanReduce       bool     // if true, drop edges implied by longer paths before writing
+	scanMinResolve   float64  // fail when fewer than this fraction of relative imports resolve
+	scanListFiles    bool     // if true, print the files the walk would parse and stop
+	scanNMDepth      int      // levels of installed packages to read; 0 keeps nodeModulesDepth from config
+	scanDiagnostics  string   // if set, write per-file import diagnostics as JSON here
+	scanInclude      []string // if set, keep only file nodes matching one of these globs in the output
+	scanExclude      []string // drop nodes matching any of these globs from the output
+)
+
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan the workspace and output the dependency graph",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Pull merged values (flags > env > config > defaults)
-		root := viper.GetString("root")
+		var cfg scan.Config
+		if err := viper.Unmarshal(&cfg); err != nil {
+			return fmt.Errorf("config unmarshal: %w", err)
+		}
+		if cfg.Root == "" {
+			cfg.Root = "."
+		}
+		mode, err := relativeIDsMode(cfg.RelativeIDs)
+		if err != nil {
+			return err
+		}
+		cfg.RelativeIDs = mode
+		if scanNoExternals {
+			cfg.ExcludeExternals = true
+		}
+		cfg.ExternalsAllowlist = append(cfg.ExternalsAllowlist, scanKeepExternal...)
+		if scanDupDeps {
+			cfg.DuplicateDeps = true
+		}
+		if scanMaxFiles != 0 {
+			cfg.MaxFiles = scanMaxFiles
+		}
+		if scanOnly != "" {
+			cfg.Only = scanOnly
+		}
+		if scanNMDepth != 0 {
+			cfg.NodeModulesDepth = scanNMDepth
+		}
+		switch scanConfineRoot {
+		case "":
+		case "warn", "fail":
+			cfg.ConfineRoot = true
+		default:
+			return fmt.Errorf("invalid --confine-root %q (want warn or fail)", scanConfineRoot)
+		}
+		if scanMinResolve < 0 || scanMinResolve > 1 {
+			return fmt.Errorf("--min-resolution must be between 0 and 1 (e.g. 0.98)")
+		}
 		out := viper.GetString("out")
 
 		// ctx lets us cancel a long walk
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 
+		// A dry run: show what the walk picks up without reading anything.
+		if scanListFiles {
+			cmd.SilenceUsage = true
+			return listScanFiles(ctx, cfg)
+		}
+
+		// NDJSON streams edges to the output as they are found instead of
+		// buffering the graph, so it can't be combined with whole-graph passes.
+		if outFormat == "ndjson" {
+			if scanFromEntries || scanFailOnCycles || scanReduce || scanMinResolve > 0 || len(scanInclude) > 0 || len(scanExclude) > 0 {
+				return fmt.Errorf("--format ndjson streams edges and can't be combined with --from-entries, --fail-on-cycles, --reduce, --min-resolution, --include, or --exclude")
+			}
+			// failures past this point are about the repo, not flag usage
+			cmd.SilenceUsage = true
+			return streamScan(ctx, cfg, out)
+		}
+
 		// Build the full-graph (walk entire tree). For multi-root entry-driven scanning,
 		// call scan.BuildGraphFromEntries instead (wired in a separate subcommand later).
-		g, err := scan.BuildGraph(ctx, root)
+		start := time.Now()
+		progress, finish := scanProgress(os.Stderr, start)
+		cfg.Progress = progress
+		g, manifest, err := scan.BuildGraphWithConfig(ctx, cfg)
+		finish()
 		if err != nil {
+			// e.g. --max-files: about the tree, not flag usage
+			cmd.SilenceUsage = true
 			return err
 		}
 
-		// Write to file or stdout (same output logic you had before).
-		var enc *json.Encoder
-		if out != "" {
-			f, err := os.Create(out)
+		// Keep only the forward closure of the configured entries ("what actually ships").
+		// Unlike BuildGraphFromEntries, resolution still benefits from the full walk.
+		if scanFromEntries {
+			provs, err := buildProviders(cfg.Entries)
 			if err != nil {
 				return err
 			}
-			defer f.Close()
-			enc = json.NewEncoder(f)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(g); err != nil {
+			entries, err := discoverEntries(ctx, cfg.Root, provs, cfg.StrictEntries)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("--from-entries: no entries discovered; check your config")
+			}
+			g = g.Subgraph(g.Reachable(entryPaths(entries)...))
+		}
+		// Shape the output without changing the walk: imports of dropped files were
+		// still resolved, so edges among the kept ones are all there.
+		if len(scanInclude) > 0 || len(scanExclude) > 0 {
+			g = filterNodes(cfg.Root, g, scanInclude, scanExclude)
+		}
+		printSummary(os.Stderr, "scan", g, manifest, time.Since(start))
+		// Diagnostics go out before any gate below, so editors see why it failed.
+		if scanDiagnostics != "" {
+			if err := writeDiagnostics(scanDiagnostics, manifest); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+		if scanVerbose {
+			printResolution(os.Stderr, "scan", manifest)
+		}
+		if cfg.DuplicateDeps {
+			printDuplicateDeps(os.Stderr, manifest)
+		}
+
+		// Report imports that escape the root; in fail mode, before writing anything.
+		if err := checkOutOfRoot(os.Stderr, manifest, cfg.Root); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		// A resolution regression (say, broken tsconfig paths) fails here rather than
+		// shipping a graph full of missing edges.
+		if scanMinResolve > 0 {
+			if err := checkCoverage(manifest, scanMinResolve); err != nil {
+				cmd.SilenceUsage = true
 				return err
 			}
-			fmt.Fprintf(os.Stderr, "wrote %s\n", out)
-			return nil
 		}
 
-		enc = json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(g)
+		// Gate on cycles before writing anything, so CI fails fast with the cycles listed.
+		if scanFailOnCycles {
+			if err := checkCycles(os.Stderr, g); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+
+		if scanReduce {
+			g = g.TransitiveReduction()
+		}
+
+		if g, err = withRelativeIDs(g, cfg.Root, cfg.RelativeIDs); err != nil {
+			return err
+		}
+
+		// Write to file or stdout (same output logic you had before).
+		return writeGraph(out, g)
 	},
 }
 
+// streamScan runs a full scan writing one NDJSON edge per line to out (or stdout)
+// as edges are discovered.
+func streamScan(ctx context.Context, cfg scan.Config, out string) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	rename, err := relativeIDFunc(cfg.Root, cfg.RelativeIDs)
+	if err != nil {
+		return err
+	}
+	if rename == nil {
+		rename = func(n string) string { return n }
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var encErr error
+	// Unique internal edges for the summary; a file importing the same module
+	// through several specs streams one line per spec.
+	internalEdges := map[[2]string]bool{}
+	cfg.OnEdge = func(from, to, spec, kind string) {
+		if encErr != nil {
+			return
+		}
+		if from != to && graph.KindOf(to) != graph.KindExternal {
+			internalEdges[[2]string{from, to}] = true
+		}
+		encErr = enc.Encode(ndjsonEdge{From: rename(from), To: rename(to), Spec: spec, Kind: kind})
+	}
+
+	start := time.Now()
+	progress, finish := scanProgress(os.Stderr, start)
+	cfg.Progress = progress
+	g, manifest, err := scan.BuildGraphWithConfig(ctx, cfg)
+	finish()
+	if err != nil {
+		return err
+	}
+	if encErr != nil {
+		return encErr
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	printSummaryEdges(os.Stderr, "scan", g, len(internalEdges), manifest, time.Since(start))
+	if scanDiagnostics != "" {
+		if err := writeDiagnostics(scanDiagnostics, manifest); err != nil {
+			return err
+		}
+	}
+	if scanVerbose {
+		printResolution(os.Stderr, "scan", manifest)
+	}
+	if cfg.DuplicateDeps {
+		printDuplicateDeps(os.Stderr, manifest)
+	}
+	if out != "" {
+		logger.Info("wrote graph", "path", out)
+	}
+	return checkOutOfRoot(os.Stderr, manifest, cfg.Root)
+}
+
+// filterNodes returns the subgraph of g without the file nodes matching none of
+// include (when given) and without any node matching exclude. Patterns match nodes
+// as written or relative to root, as --changed globs do. "pkg:" externals are kept
+// while a kept file imports them, unless exclude matches them.
+func filterNodes(root string, g *graph.Graph, include, exclude []string) *graph.Graph {
+	keep := map[string]bool{}
+	if len(include) == 0 {
+		for _, n := range g.Nodes() {
+			keep[n] = graph.KindOf(n) != graph.KindExternal
+		}
+	}
+	for _, pat := range include {
+		for _, n := range matchNodes(root, g, pat) {
+			keep[n] = graph.KindOf(n) != graph.KindExternal
+		}
+	}
+	excluded := map[string]bool{}
+	for _, pat := range exclude {
+		for _, n := range matchNodes(root, g, pat) {
+			excluded[n] = true
+		}
+	}
+	var nodes []string
+	for n, ok := range keep {
+		if !ok || excluded[n] {
+			continue
+		}
+		nodes = append(nodes, n)
+		for _, to := range g.OutNeighbors(n) {
+			if graph.KindOf(to) == graph.KindExternal && !excluded[to] {
+				nodes = append(nodes, to)
+			}
+		}
+	}
+	return g.Subgraph(nodes)
+}
+
+// listScanFiles prints, one per line, the files a scan with cfg would parse, and
+// with --verbose the ones skipped by name (and why) on stderr.
+func listScanFiles(ctx context.Context, cfg scan.Config) error {
+	files, skipped, err := scan.ListFiles(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, f := range files {
+		fmt.Fprintln(w, f)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if scanVerbose {
+		for _, s := range skipped {
+			fmt.Fprintf(os.Stderr, "skip: %s (%s)\n", s.File, s.Reason)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "scan: %d files would be parsed, %d skipped by name\n", len(files), len(skipped))
+	return nil
+}
+
+// scanProgress returns a progress callback that redraws a single stderr line (at most
+// every 200ms) with files done, the percentage, and an ETA once the walk has counted
+// every file, plus a finish func that ends the line if anything was drawn.
+func scanProgress(w io.Writer, start time.Time) (func(scan.Progress), func()) {
+	last := start // quick scans finish before anything is drawn
+	drawn := false
+	progress := func(p scan.Progress) {
+		now := time.Now()
+		if now.Sub(last) < 200*time.Millisecond {
+			return
+		}
+		last, drawn = now, true
+		if !p.Counted || p.Found == 0 {
+			fmt.Fprintf(w, "\rscan: %d/%d+ files (counting...)          ", p.Done, p.Found)
+			return
+		}
+		elapsed := now.Sub(start)
+		eta := time.Duration(float64(elapsed) / float64(p.Done) * float64(p.Found-p.Done))
+		fmt.Fprintf(w, "\rscan: %d/%d files (%d%%) eta %s          ",
+			p.Done, p.Found, p.Done*100/p.Found, eta.Round(time.Second))
+	}
+	finish := func() {
+		if drawn {
+			fmt.Fprintln(w)
+		}
+	}
+	return progress, finish
+}
+
+// checkOutOfRoot prints imports that resolved outside root and, with --confine-root=fail,
+// returns an error if there were any.
+func checkOutOfRoot(w io.Writer, m *scan.Manifest, root string) error {
+	for _, o := range m.OutOfRoot {
+		fmt.Fprintf(w, "outside root: %s imports %q -> %s\n", o.File, o.Spec, o.To)
+	}
+	if len(m.OutOfRoot) > 0 && scanConfineRoot == "fail" {
+		return fmt.Errorf("found %d import(s) resolving outside %s", len(m.OutOfRoot), root)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(scanCmd)
+	addOutputFlags(scanCmd)
+	addRelativeIDsFlag(scanCmd)
+	scanCmd.Flags().BoolVar(&scanFromEntries, "from-entries", false, "prune the graph to files reachable from configured entries")
+	scanCmd.Flags().StringVar(&scanConfineRoot, "confine-root", "", "report imports resolving outside --root (also via symlinks): warn|fail")
+	scanCmd.Flags().Lookup("confine-root").NoOptDefVal = "fail"
+	scanCmd.Flags().BoolVar(&scanNoExternals, "no-externals", false, "don't record pkg: externals (same as excludeExternals in config)")
+	scanCmd.Flags().StringSliceVar(&scanKeepExternal, "keep-external", nil, "package glob to keep despite --no-externals (repeatable, e.g. react,@acme/*)")
+	scanCmd.Flags().BoolVar(&scanVerbose, "verbose", false, "print how specifiers were resolved (relative, alias, baseUrl, bare, ...)")
+	scanCmd.Flags().BoolVar(&scanDupDeps, "duplicate-deps", false, "report packages imported from more than one node_modules copy (also duplicateDeps in config)")
+	scanCmd.Flags().IntVar(&scanNMDepth, "include-node-modules-depth", 0, "record package-to-package edges by reading imported packages in node_modules, this many levels deep (also nodeModulesDepth in config)")
+	scanCmd.Flags().StringSliceVar(&scanInclude, "include", nil, "keep only file nodes matching this glob in the output, e.g. 'src/**' (repeatable; the walk is unchanged)")
+	scanCmd.Flags().StringSliceVar(&scanExclude, "exclude", nil, "drop nodes matching this glob from the output, e.g. '**/*.stories.tsx' (repeatable; the walk is unchanged)")
+	scanCmd.Flags().StringVar(&scanDiagnostics, "diagnostics", "", "write unresolved imports, self-imports, and (with --confine-root) out-of-root imports per file as JSON to this path, for editors")
+	scanCmd.Flags().StringVar(&scanOnly, "only", "", "walk only this directory (relative to --root); imports leaving it are resolved and marked as boundary nodes")
+	scanCmd.Flags().Float64Var(&scanMinResolve, "min-resolution", 0, "fail when fewer than this fraction of relative imports resolve to a file (e.g. 0.98)")
+	scanCmd.Flags().BoolVar(&scanListFiles, "list-files", false, "print the files the walk would parse (after skip globs and skipped dirs) without reading them, then exit")
+	scanCmd.Flags().IntVar(&scanMaxFiles, "max-files", 0, "fail once the walk finds more source files than this (default 200000, -1 for no limit)")
+	scanCmd.Flags().BoolVar(&scanReduce, "reduce", false, "drop edges implied by longer paths (transitive reduction) to declutter visualizations")
+	scanCmd.Flags().BoolVar(&scanFailOnCycles, "fail-on-cycles", false, "print circular imports among internal files and exit non-zero if any exist")
 }
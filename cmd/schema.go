@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// schemaCmd prints the JSON Schema for graph.json, generated from graph.Document
+// so it can't drift from what scan, entries, and export write.
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for the graph document (nodes, edges, attrs, kinds)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return newJSONEncoder(os.Stdout).Encode(graph.Schema())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	addCompactFlag(schemaCmd)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/philjestin/philtographer/internal/graph"
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+// printSummary writes a one-line scale report for a freshly built graph.
+// m may be nil when the builder doesn't produce a manifest; file count then
+// falls back to internal nodes and the unresolved count is omitted.
+func printSummary(w io.Writer, label string, g *graph.Graph, m *scan.Manifest, elapsed time.Duration) {
+	internalEdges := 0
+	g.ForEachEdge(func(from, to string) {
+		if graph.KindOf(to) != graph.KindExternal {
+			internalEdges++
+		}
+	})
+	printSummaryEdges(w, label, g, internalEdges, m, elapsed)
+}
+
+// printSummaryEdges is printSummary for a graph whose edges were streamed instead
+// of stored (scan.Config.OnEdge): the caller counts the unique internal edges.
+func printSummaryEdges(w io.Writer, label string, g *graph.Graph, internalEdges int, m *scan.Manifest, elapsed time.Duration) {
+	externals, files := 0, 0
+	for _, n := range g.Nodes() {
+		if graph.KindOf(n) == graph.KindExternal {
+			externals++
+		} else {
+			files++
+		}
+	}
+	if m == nil {
+		fmt.Fprintf(w, "%s: files=%d internal-edges=%d externals=%d elapsed=%s\n",
+			label, files, internalEdges, externals, elapsed.Round(time.Millisecond))
+		return
+	}
+	skipped := ""
+	if len(m.Skipped) > 0 {
+		skipped = fmt.Sprintf(" skipped=%d", len(m.Skipped))
+	}
+	fmt.Fprintf(w, "%s: files=%d internal-edges=%d externals=%d unresolved=%d resolved=%s%s elapsed=%s\n",
+		label, m.Files, internalEdges, externals, len(m.Unresolved), formatCoverage(m.Resolution.Coverage()), skipped, elapsed.Round(time.Millisecond))
+	warnAmbiguousIndexes(m)
+	warnComputedImports(m)
+	warnNotUTF8(m)
+}
+
+// formatCoverage formats a resolution coverage fraction as a percentage. It
+// truncates rather than rounds, so 99.996% isn't shown as a passing 100.00%.
+func formatCoverage(c float64) string {
+	return fmt.Sprintf("%.2f%%", math.Floor(c*10000)/100)
+}
+
+// checkCoverage fails when fewer than min of the relative imports resolved.
+func checkCoverage(m *scan.Manifest, min float64) error {
+	if c := m.Resolution.Coverage(); c < min {
+		return fmt.Errorf("relative imports resolved: %s (%d of %d), below --min-resolution %s",
+			formatCoverage(c), m.Resolution.Relative, m.Resolution.Relative+m.Resolution.RelativeFailed, formatCoverage(min))
+	}
+	return nil
+}
+
+// warnAmbiguousIndexes warns about directories that resolved to one of several
+// index files, since the pick may not be the intended entry.
+func warnAmbiguousIndexes(m *scan.Manifest) {
+	amb := slices.Clone(m.AmbiguousIndexes)
+	sort.Slice(amb, func(i, j int) bool { return amb[i].Dir < amb[j].Dir })
+	for _, a := range amb {
+		warnAmbiguousIndex(a)
+	}
+}
+
+// warnAmbiguousIndex warns about one such directory. Resolvers from newResolver
+// call it directly, as resolution finds them.
+func warnAmbiguousIndex(a scan.AmbiguousIndex) {
+	others := make([]string, len(a.Others))
+	for i, o := range a.Others {
+		others[i] = filepath.Base(o)
+	}
+	logger.Warn("several index files; set \"extensions\" to change the order",
+		"dir", a.Dir, "using", filepath.Base(a.Chosen), "over", strings.Join(others, ", "))
+}
+
+// warnComputedImports notes how many import()/require() calls have a computed
+// argument, and so edges the graph can't show; each site is a debug log.
+func warnComputedImports(m *scan.Manifest) {
+	if len(m.Computed) == 0 {
+		return
+	}
+	computed := slices.Clone(m.Computed)
+	sort.Slice(computed, func(i, j int) bool {
+		if computed[i].File != computed[j].File {
+			return computed[i].File < computed[j].File
+		}
+		return computed[i].Line < computed[j].Line
+	})
+	files := map[string]bool{}
+	for _, c := range computed {
+		files[c.File] = true
+		logger.Debug("dynamic import can't be resolved", "file", c.File, "line", c.Line, "expr", c.Expr)
+	}
+	logger.Warn("dynamic imports with computed specifiers can't be resolved; the graph is missing their edges",
+		"sites", len(computed), "files", len(files))
+}
+
+// warnNotUTF8 names the files skipped because they aren't valid UTF-8: unlike
+// the other skip reasons, nobody chose to leave them out.
+func warnNotUTF8(m *scan.Manifest) {
+	for _, s := range m.Skipped {
+		if strings.HasPrefix(s.Reason, scan.ReasonNotUTF8) {
+			logger.Warn("skipped a file that isn't valid UTF-8; re-save it as UTF-8 to scan it", "file", s.File, "reason", s.Reason)
+		}
+	}
+}
+
+// printDuplicateDeps lists packages installed in more than one node_modules
+// location that the scan's imports actually reached, with each copy's version and
+// importer count.
+func printDuplicateDeps(w io.Writer, m *scan.Manifest) {
+	fmt.Fprintf(w, "duplicate-deps (%d):\n", len(m.DuplicateDeps))
+	for _, d := range m.DuplicateDeps {
+		fmt.Fprintf(w, "  %s\n", d.Name)
+		for _, c := range d.Copies {
+			version := c.Version
+			if version == "" {
+				version = "?"
+			}
+			fmt.Fprintf(w, "    %s  %s  (%d importers)\n", version, c.Dir, c.Importers)
+		}
+	}
+}
+
+// printResolution writes the manifest's resolution breakdown on one line.
+func printResolution(w io.Writer, label string, m *scan.Manifest) {
+	fmt.Fprintf(w, "%s: resolution: %s\n", label, m.Resolution)
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+var tuiGraph string
+
+// tuiCmd is a terminal browser over a graph.json, for when the web UI isn't reachable (e.g. over SSH).
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse a graph.json in the terminal: navigate nodes, dependencies, and dependents",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tuiGraph == "" {
+			return fmt.Errorf("--graph is required (path to graph.json)")
+		}
+		g, err := loadGraph(tuiGraph)
+		if err != nil {
+			return err
+		}
+		m := newTUIModel(g)
+		_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+		return err
+	},
+}
+
+// tuiItem is one row of the list: a node, and how it relates to the focused node.
+type tuiItem struct {
+	node   string
+	prefix string // "" when browsing all nodes, "→ " for dependencies, "← " for dependents
+}
+
+// tuiModel browses all nodes, or the direct neighbors of a focused node. Enter
+// focuses the selected node, backspace returns to the previous focus.
+type tuiModel struct {
+	g         *graph.Graph
+	all       []string
+	focus     string // "" = browsing all nodes
+	history   []string
+	deps      int // transitive dependencies of focus, counted when it changes
+	impacted  int // transitive dependents of focus, counted when it changes
+	items     []tuiItem
+	cursor    int
+	offset    int
+	height    int
+	searching bool
+	query     string
+}
+
+func newTUIModel(g *graph.Graph) *tuiModel {
+	m := &tuiModel{g: g, all: g.Nodes(), height: 24}
+	m.refresh()
+	return m
+}
+
+func (m *tuiModel) Init() tea.Cmd { return nil }
+
+// setFocus focuses node and counts its transitive neighbors once, rather than on
+// every render.
+func (m *tuiModel) setFocus(node string) {
+	m.focus = node
+	m.deps, m.impacted = 0, 0
+	if node != "" {
+		m.deps, m.impacted = len(m.g.Dependencies(node)), len(m.g.Impacted(node))
+	}
+	m.query = ""
+	m.refresh()
+}
+
+// refresh rebuilds the list for the current focus and search query.
+func (m *tuiModel) refresh() {
+	m.items = m.items[:0]
+	match := func(n string) bool {
+		return m.query == "" || strings.Contains(strings.ToLower(n), strings.ToLower(m.query))
+	}
+	if m.focus == "" {
+		for _, n := range m.all {
+			if match(n) {
+				m.items = append(m.items, tuiItem{node: n})
+			}
+		}
+	} else {
+		for _, n := range m.g.OutNeighbors(m.focus) {
+			if match(n) {
+				m.items = append(m.items, tuiItem{node: n, prefix: "→ "})
+			}
+		}
+		for _, n := range m.g.InNeighbors(m.focus) {
+			if match(n) {
+				m.items = append(m.items, tuiItem{node: n, prefix: "← "})
+			}
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+// listHeight is the number of rows left for the list after the header and footer.
+func (m *tuiModel) listHeight() int {
+	return max(m.height-6, 1)
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if m.searching {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.searching = false
+			case tea.KeyEsc:
+				m.searching, m.query = false, ""
+				m.refresh()
+			case tea.KeyBackspace:
+				if m.query != "" {
+					m.query = m.query[:len(m.query)-1]
+					m.refresh()
+				}
+			case tea.KeyRunes:
+				m.query += string(msg.Runes)
+				m.refresh()
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case "/":
+			m.searching = true
+		case "esc":
+			m.query = ""
+			m.refresh()
+		case "enter", "right", "l":
+			if len(m.items) > 0 {
+				m.history = append(m.history, m.focus)
+				m.setFocus(m.items[m.cursor].node)
+			}
+		case "backspace", "left", "h":
+			if len(m.history) > 0 {
+				prev := m.history[len(m.history)-1]
+				m.history = m.history[:len(m.history)-1]
+				m.setFocus(prev)
+			}
+		}
+		// keep the cursor inside the visible window
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		}
+		if m.cursor >= m.offset+m.listHeight() {
+			m.offset = m.cursor - m.listHeight() + 1
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+	if m.focus == "" {
+		fmt.Fprintf(&b, "All nodes (%d)\n", len(m.all))
+	} else {
+		in, out := m.g.Degrees(m.focus)
+		fmt.Fprintf(&b, "%s\n", m.focus)
+		fmt.Fprintf(&b, "imports=%d imported-by=%d dependencies=%d impacted=%d\n",
+			out, in, m.deps, m.impacted)
+	}
+	if m.searching || m.query != "" {
+		fmt.Fprintf(&b, "/%s\n", m.query)
+	} else {
+		b.WriteString("\n")
+	}
+
+	end := min(m.offset+m.listHeight(), len(m.items))
+	for i := m.offset; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		it := m.items[i]
+		fmt.Fprintf(&b, "%s%s%s\n", cursor, it.prefix, it.node)
+	}
+	if len(m.items) == 0 {
+		b.WriteString("  (no nodes)\n")
+	}
+
+	b.WriteString("\n↑/↓ move · enter focus · backspace back · / search · esc clear · q quit\n")
+	return b.String()
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+	tuiCmd.Flags().StringVar(&tuiGraph, "graph", "", "path to graph.json to browse")
+}
@@ -1,11 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"mime"
 	"net/http"
 	"os"
@@ -24,87 +25,192 @@ import (
 var uiFS embed.FS
 
 var (
-	uiAddr   string
-	uiGraph  string
-	uiEvents string
+	uiAddr    string
+	uiGraphs  []string // paths, or name=path pairs
+	uiEvents  string
+	uiHistory string
+	uiInvert  bool // if true, serve every graph with its edges reversed
 )
 
+// uiGraph is one graph the UI can show, served at /graph.json?name=<Name>.
+type uiGraph struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// parseUIGraphs reads --graph values: "name=path", or a bare path named after its
+// file (graph.json -> "graph"). The first graph is the default.
+func parseUIGraphs(values []string) ([]uiGraph, error) {
+	var graphs []uiGraph
+	seen := map[string]bool{}
+	for _, v := range values {
+		name, p, ok := strings.Cut(v, "=")
+		// a path containing "=" (and no name before it) is still a path
+		if !ok || name == "" || strings.ContainsAny(name, `/\`) {
+			name, p = strings.TrimSuffix(filepath.Base(v), filepath.Ext(v)), v
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("--graph: two graphs named %q; use name=path to tell them apart", name)
+		}
+		seen[name] = true
+		graphs = append(graphs, uiGraph{Name: name, Path: p})
+	}
+	return graphs, nil
+}
+
+// findUIGraph returns the graph called name, or the default for "".
+func findUIGraph(graphs []uiGraph, name string) (uiGraph, bool) {
+	if name == "" {
+		return graphs[0], true
+	}
+	for _, g := range graphs {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return uiGraph{}, false
+}
+
 // uiCmd serves a small static UI to visualize a graph.json via D3.
 var uiCmd = &cobra.Command{
 	Use:   "ui",
 	Short: "Serve a local UI for viewing graph.json as a force-directed graph",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if uiGraph == "" {
+		if len(uiGraphs) == 0 {
 			return fmt.Errorf("--graph is required (path to graph.json)")
 		}
-		// Validate graph file exists and is valid JSON once on startup for faster feedback.
-		f, err := os.Open(uiGraph)
+		graphs, err := parseUIGraphs(uiGraphs)
 		if err != nil {
-			return fmt.Errorf("open --graph: %w", err)
+			return err
 		}
-		defer f.Close()
-		var tmp interface{}
-		if err := json.NewDecoder(f).Decode(&tmp); err != nil {
-			return fmt.Errorf("invalid graph JSON: %w", err)
-		}
-
-		mux := http.NewServeMux()
-		// Serve embedded static files
-		fs := http.FS(uiFS)
-		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			p := r.URL.Path
-			if p == "/" {
-				p = "/ui_static/index.html"
-			} else if p == "/app.js" || p == "/styles.css" {
-				p = "/ui_static" + p
-			} else if p == "/favicon.ico" {
-				w.WriteHeader(http.StatusNoContent)
-				return
-			} else if p == "/graph.json" {
-				serveGraphJSON(w, uiGraph)
-				return
-			} else if p == "/events.json" {
-				serveGraphJSON(w, uiEvents)
-				return
-			} else if p == "/ws" {
-				serveWS(w, r)
-				return
-			} else {
-				// try to serve any other embedded asset under ui_static
-				p = "/ui_static" + p
+		// Validate graph files exist and are valid JSON once on startup for faster feedback.
+		for _, g := range graphs {
+			if err := checkGraphJSON(g.Path); err != nil {
+				return fmt.Errorf("--graph %s: %w", g.Name, err)
 			}
+		}
 
-			// Trim the leading slash for embedded FS access
-			p = strings.TrimPrefix(p, "/")
+		if uiEvents == "" {
+			// default to sibling of the first graph
+			uiEvents = strings.TrimSuffix(graphs[0].Path, filepath.Ext(graphs[0].Path)) + "-events.json"
+		}
+		// Start file watcher to notify clients on changes
+		watched := []string{uiEvents}
+		for _, g := range graphs {
+			watched = append(watched, g.Path)
+		}
+		startFileWatcher(watched...)
+		logger.Info("UI listening", "url", "http://localhost"+uiAddr, "graphs", len(graphs), "graph", graphs[0].Path, "events", uiEvents)
+		return http.ListenAndServe(uiAddr, newUIHandler(graphs, uiEvents, uiHistory))
+	},
+}
 
-			f, err := fs.Open(p)
-			if err != nil {
-				http.NotFound(w, r)
+// newUIHandler serves the UI: the embedded static files, graphs at /graph.json,
+// the events file at /events.json, the watch history at /api/events-history, and
+// live-update notifications on /ws.
+func newUIHandler(graphs []uiGraph, events, history string) http.Handler {
+	mux := http.NewServeMux()
+	// Serve embedded static files
+	fs := http.FS(uiFS)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		if p == "/" {
+			p = "/ui_static/index.html"
+		} else if p == "/app.js" || p == "/styles.css" {
+			p = "/ui_static" + p
+		} else if p == "/favicon.ico" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		} else if p == "/graph.json" {
+			g, ok := findUIGraph(graphs, r.URL.Query().Get("name"))
+			if !ok {
+				http.Error(w, "no graph named "+r.URL.Query().Get("name"), http.StatusNotFound)
 				return
 			}
-			defer f.Close()
-
-			// Set content-type from extension when possible
-			if ct := mime.TypeByExtension(path.Ext(p)); ct != "" {
-				w.Header().Set("Content-Type", ct)
+			exclude := r.URL.Query().Get("exclude")
+			invert := uiInvert || r.URL.Query().Get("invert") == "1"
+			if exclude != "" || invert {
+				serveGraphRewritten(w, g.Path, strings.Split(exclude, ","), invert)
+				return
 			}
-			// Prevent aggressive caching of embedded assets during development
-			w.Header().Set("Cache-Control", "no-store")
+			serveGraphJSON(w, g.Path)
+			return
+		} else if p == "/api/graphs" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(graphs)
+			return
+		} else if p == "/events.json" {
+			serveGraphJSON(w, events)
+			return
+		} else if p == "/api/events-history" {
+			serveEventsHistory(w, history)
+			return
+		} else if p == "/ws" {
+			serveWS(w, r)
+			return
+		} else {
+			// try to serve any other embedded asset under ui_static
+			p = "/ui_static" + p
+		}
 
-			if _, err := io.Copy(w, f); err != nil {
-				// TODO: optional logging
-			}
-		})
+		// Trim the leading slash for embedded FS access
+		p = strings.TrimPrefix(p, "/")
 
-		if uiEvents == "" {
-			// default to sibling of graph
-			uiEvents = strings.TrimSuffix(uiGraph, filepath.Ext(uiGraph)) + "-events.json"
+		f, err := fs.Open(p)
+		if err != nil {
+			http.NotFound(w, r)
+			return
 		}
-		// Start file watcher to notify clients on changes
-		startFileWatcher(uiGraph, uiEvents)
-		log.Printf("UI listening on http://localhost%s (graph: %s, events: %s)\n", uiAddr, uiGraph, uiEvents)
-		return http.ListenAndServe(uiAddr, mux)
-	},
+		defer f.Close()
+
+		// Set content-type from extension when possible
+		if ct := mime.TypeByExtension(path.Ext(p)); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		// Prevent aggressive caching of embedded assets during development
+		w.Header().Set("Cache-Control", "no-store")
+
+		if _, err := io.Copy(w, f); err != nil {
+			// TODO: optional logging
+		}
+	})
+	return mux
+}
+
+// checkGraphJSON reports whether the file at path can be opened and decoded as JSON.
+func checkGraphJSON(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var tmp interface{}
+	if err := json.NewDecoder(f).Decode(&tmp); err != nil {
+		return fmt.Errorf("invalid graph JSON: %w", err)
+	}
+	return nil
+}
+
+// serveEventsHistory serves the watch --history JSON lines file as a JSON array,
+// oldest first. A missing file (or no --history) is an empty history.
+func serveEventsHistory(w http.ResponseWriter, path string) {
+	events := []json.RawMessage{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			// skip blank lines and a line cut short by a concurrent rewrite
+			if json.Valid(line) {
+				events = append(events, line)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(events)
 }
 
 // serveGraphJSON streams the file from disk for each request to allow live reload after rescans.
@@ -120,6 +226,80 @@ func serveGraphJSON(w http.ResponseWriter, path string) {
 	io.Copy(w, f)
 }
 
+// serveGraphRewritten serves the graph file without edges whose kind is in kinds
+// (e.g. /graph.json?exclude=imported) and, when invert is set, with every edge's
+// From and To swapped (/graph.json?invert=1 or ui --invert). Nodes are kept, and so
+// is everything else in the file. Legacy or external files with a "graphs" array of
+// per-change graphs, which app.js also understands, have those edges rewritten the
+// same way.
+func serveGraphRewritten(w http.ResponseWriter, path string, kinds []string, invert bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	drop := map[string]bool{}
+	for _, k := range kinds {
+		if k = strings.TrimSpace(k); k != "" {
+			drop[k] = true
+		}
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		http.Error(w, "invalid graph JSON: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := rewriteEdges(doc, drop, invert); err != nil {
+		http.Error(w, "invalid graph JSON: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var subs []map[string]json.RawMessage
+	if raw, ok := doc["graphs"]; ok && json.Unmarshal(raw, &subs) == nil {
+		for _, sub := range subs {
+			if err := rewriteEdges(sub, drop, invert); err != nil {
+				http.Error(w, "invalid graph JSON: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		doc["graphs"], _ = json.Marshal(subs)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// rewriteEdges removes edges whose "kind" is in drop from doc's "edges" array and,
+// when invert is set, swaps the "From" and "To" of the rest, leaving the other
+// fields of each edge as they were.
+func rewriteEdges(doc map[string]json.RawMessage, drop map[string]bool, invert bool) error {
+	raw, ok := doc["edges"]
+	if !ok {
+		return nil
+	}
+	var edges []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &edges); err != nil {
+		return err
+	}
+	kept := make([]map[string]json.RawMessage, 0, len(edges))
+	for _, e := range edges {
+		var kind string
+		if k, ok := e["kind"]; ok {
+			if err := json.Unmarshal(k, &kind); err != nil {
+				return err
+			}
+		}
+		if drop[kind] {
+			continue
+		}
+		if invert {
+			e["From"], e["To"] = e["To"], e["From"]
+		}
+		kept = append(kept, e)
+	}
+	doc["edges"], _ = json.Marshal(kept)
+	return nil
+}
+
 // --- SSE push for live updates ---
 var (
 	sseClientsMu sync.Mutex
@@ -198,21 +378,38 @@ func wsBroadcast() {
 	wsClientsMu.Unlock()
 }
 
-func startFileWatcher(graphPath, eventsPath string) {
+// notifyClients tells every connected SSE and WebSocket client to reload.
+func notifyClients() {
+	sseClientsMu.Lock()
+	for ch := range sseClients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	sseClientsMu.Unlock()
+	wsBroadcast()
+}
+
+// startFileWatcher notifies clients whenever one of paths (the graphs and events
+// file) is written.
+func startFileWatcher(paths ...string) {
+	targets := map[string]bool{}
+	for _, p := range paths {
+		if p != "" {
+			targets[p] = true
+		}
+	}
 	go func() {
 		watcher, err := fsnotify.NewWatcher()
 		if err != nil {
-			log.Println("sse watcher:", err)
+			logger.Error("file watcher", "err", err)
 			return
 		}
 		defer watcher.Close()
-		add := func(p string) {
-			if p != "" {
-				_ = watcher.Add(filepath.Dir(p))
-			}
+		for p := range targets {
+			_ = watcher.Add(filepath.Dir(p))
 		}
-		add(graphPath)
-		add(eventsPath)
 		for {
 			select {
 			case ev, ok := <-watcher.Events:
@@ -220,19 +417,11 @@ func startFileWatcher(graphPath, eventsPath string) {
 					return
 				}
 				// Only notify for the target files
-				if (graphPath != "" && (ev.Name == graphPath)) || (eventsPath != "" && (ev.Name == eventsPath)) {
-					sseClientsMu.Lock()
-					for ch := range sseClients {
-						select {
-						case ch <- struct{}{}:
-						default:
-						}
-					}
-					sseClientsMu.Unlock()
-					wsBroadcast()
+				if targets[ev.Name] {
+					notifyClients()
 				}
 			case err := <-watcher.Errors:
-				log.Println("sse watcher error:", err)
+				logger.Error("file watcher", "err", err)
 			}
 		}
 	}()
@@ -241,6 +430,8 @@ func startFileWatcher(graphPath, eventsPath string) {
 func init() {
 	rootCmd.AddCommand(uiCmd)
 	uiCmd.Flags().StringVar(&uiAddr, "addr", ":8080", "address to listen on (e.g. :8080)")
-	uiCmd.Flags().StringVar(&uiGraph, "graph", "", "path to graph.json to serve at /graph.json")
+	uiCmd.Flags().StringSliceVar(&uiGraphs, "graph", nil, "graph.json to serve at /graph.json; repeat as name=path to switch between several (/graph.json?name=...)")
+	uiCmd.Flags().StringVar(&uiHistory, "history", "", "path to the watch --history file to serve at /api/events-history")
+	uiCmd.Flags().BoolVar(&uiInvert, "invert", false, "serve edges reversed (B -> A: \"B is depended on by A\"); a single request can ask with /graph.json?invert=1")
 	uiCmd.Flags().StringVar(&uiEvents, "events", "", "path to events.json to serve at /events.json")
 }
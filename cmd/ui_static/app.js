@@ -10,6 +10,9 @@
   const subgraphBtn = document.getElementById('subgraph');
   const resetBtn = document.getElementById('reset');
   const minDegreeInput = document.getElementById('minDegree');
+  const excludeKindsInput = document.getElementById('excludeKinds');
+  const graphPicker = document.getElementById('graphPicker');
+  const graphPickerLabel = document.getElementById('graphPickerLabel');
   const toggleLabels = document.getElementById('toggleLabels');
   const hideNonFocused = document.getElementById('hideNonFocused');
   const layoutTreeBtn = document.getElementById('layoutTree');
@@ -20,6 +23,8 @@
   const changedList = document.getElementById('changedList');
   const impactedList = document.getElementById('impactedList');
   const viewsList = document.getElementById('viewsList');
+  const historyTitle = document.getElementById('historyTitle');
+  const historyList = document.getElementById('historyList');
   const resizer = document.getElementById('resizer');
 
   const hasPixi = typeof PIXI !== 'undefined';
@@ -37,10 +42,39 @@
 
   status.textContent = 'Loading graph.json…';
 
+  // Edge kinds to hide are filtered server-side; ?exclude= on the page URL presets them
+  const pageParams = new URLSearchParams(window.location.search);
+  if (excludeKindsInput) excludeKindsInput.value = pageParams.get('exclude') || '';
+
+  // With several --graph name=path pairs, a picker switches between them; ?graph= presets it
+  try {
+    const r = await fetch('/api/graphs', { cache: 'no-cache' });
+    const graphs = r.ok ? await r.json() : [];
+    if (graphPicker && Array.isArray(graphs) && graphs.length > 1) {
+      for (const g of graphs) {
+        const opt = document.createElement('option');
+        opt.value = g.name; opt.textContent = g.name; opt.title = g.path;
+        graphPicker.appendChild(opt);
+      }
+      const wanted = pageParams.get('graph');
+      if (wanted && graphs.some(g => g.name === wanted)) graphPicker.value = wanted;
+      if (graphPickerLabel) graphPickerLabel.hidden = false;
+    }
+  } catch (e) { console.warn('graph list unavailable', e); }
+
+  function graphURL() {
+    const params = new URLSearchParams();
+    if (graphPicker?.value) params.set('name', graphPicker.value);
+    const exclude = (excludeKindsInput?.value || '').trim();
+    if (exclude) params.set('exclude', exclude);
+    const q = params.toString();
+    return q ? `/graph.json?${q}` : '/graph.json';
+  }
+
   let graph;
   let commonRoot = '';
   try {
-    const res = await fetch('/graph.json', { cache: 'no-cache' });
+    const res = await fetch(graphURL(), { cache: 'no-cache' });
     if (!res.ok) throw new Error(String(res.status));
     graph = await res.json();
   } catch (err) {
@@ -130,14 +164,27 @@
 
   let selectedId = null;
 
+  // Color by node kind when the graph carries kinds; otherwise cycle through the palette.
+  const kindColors = { internal: 0x1f77b4, external: 0xff7f0e, style: 0xe377c2, asset: 0x2ca02c, data: 0xbcbd22 };
+  function nodeColor(id, i) {
+    const kind = graph.kinds && graph.kinds[id];
+    return (kind && kindColors[kind]) || baseColors[i % baseColors.length];
+  }
+
+  // Size nodes by line count when the graph carries attrs; otherwise keep the default dot.
+  function nodeRadius(id) {
+    const lines = graph.attrs && graph.attrs[id] && graph.attrs[id].lines;
+    return lines ? 3.5 + Math.min(6, Math.sqrt(lines) / 5) : 3.5;
+  }
+
   function showTooltip(text, x, y) { tooltip.textContent = text; tooltip.style.left = `${x + 10}px`; tooltip.style.top = `${y + 10}px`; tooltip.style.display = 'block'; }
   function hideTooltip() { tooltip.style.display = 'none'; }
 
   function createScene() {
     edgesLayer.clear(); nodesLayer.removeChildren(); labelsLayer.removeChildren(); nodeSprite.clear?.(); nodeLabel.clear?.(); nodeSprite.forEach((_, k) => nodeSprite.delete(k)); nodeLabel.forEach((_, k) => nodeLabel.delete(k));
     for (let i = 0; i < nodes.length; i++) {
-      const n = nodes[i]; const color = baseColors[i % baseColors.length]; const g = new PIXI.Graphics();
-      g.beginFill(color).drawCircle(0, 0, 3.5).endFill(); g.eventMode = 'static'; g.cursor = 'pointer';
+      const n = nodes[i]; const color = nodeColor(n.id, i); const g = new PIXI.Graphics();
+      g.beginFill(color).drawCircle(0, 0, nodeRadius(n.id)).endFill(); g.eventMode = 'static'; g.cursor = 'pointer';
       g.on('pointerdown', () => { selectedId = n.id; focusOn(n.id); highlightSelected(); });
       g.on('pointerover', (ev) => { showTooltip(n.id, ev.clientX, ev.clientY); }); g.on('pointermove', (ev) => { showTooltip(n.id, ev.clientX, ev.clientY); }); g.on('pointerout', hideTooltip);
       nodesLayer.addChild(g); nodeSprite.set(n.id, g);
@@ -158,6 +205,7 @@
       }
     }
   } catch {}
+  loadHistory();
 
   function highlightSelected() { for (const [id, sprite] of nodeSprite) { sprite.lineStyle?.(0); if (id === selectedId) { sprite.lineStyle?.(1.5, 0x000000, 1); } } }
   function toggleLabelVisibility() { const on = !!toggleLabels?.checked; labelsLayer.visible = on; }
@@ -334,15 +382,27 @@
     try {
       const r = await fetch('/events.json', { cache: 'no-cache' });
       if (!r.ok) return; const evt = await r.json(); if (!evt || typeof evt.ts !== 'number' || evt.ts <= lastTs) return; lastTs = evt.ts;
-      const gres = await fetch('/graph.json', { cache: 'no-cache' }); if (!gres.ok) return; graph = await gres.json();
+      const gres = await fetch(graphURL(), { cache: 'no-cache' }); if (!gres.ok) return; graph = await gres.json();
       commonRoot = computeCommonRoot(graph.nodes || []);
       const fullNow = computeFiltered(); nodes = fullNow.nodes; links = fullNow.links; rebuildAdjacency(); simulation.nodes(nodes); simulation.force('link').links(links); simulation.alpha(0.4).restart(); createScene(); status.textContent = `Nodes: ${nodes.length}, Edges: ${links.length}`;
       renderDiff(evt.changed, evt.impacted);
+      loadHistory();
       const list = Array.isArray(evt.impacted) && evt.impacted.length ? evt.impacted : (Array.isArray(evt.changed) ? evt.changed : []);
       if (list.length) { const set = new Set(list.filter(Boolean)); applyFocus(set); selectedId = list[0]; highlightSelected(); }
     } catch (e) { console.error('update error', e); }
   }
 
+  // Refetch the graph when the hidden edge kinds change or another graph is picked
+  async function reloadGraph() {
+    try {
+      const gres = await fetch(graphURL(), { cache: 'no-cache' }); if (!gres.ok) return; graph = await gres.json();
+      commonRoot = computeCommonRoot(graph.nodes || []);
+      const fullNow = computeFiltered(); nodes = fullNow.nodes; links = fullNow.links; rebuildAdjacency(); simulation.nodes(nodes); simulation.force('link').links(links); simulation.alpha(0.4).restart(); createScene(); status.textContent = `Nodes: ${nodes.length}, Edges: ${links.length}`;
+    } catch (e) { console.error('reload error', e); }
+  }
+  excludeKindsInput?.addEventListener('change', reloadGraph);
+  graphPicker?.addEventListener('change', reloadGraph);
+
   function connectWS() {
     try {
       const proto = (location.protocol === 'https:') ? 'wss' : 'ws';
@@ -367,6 +427,26 @@
   }
   connectWS();
 
+  // Timeline of recent events from watch --history, newest first; clicking one replays its diff.
+  async function loadHistory() {
+    if (!historyList) return;
+    try {
+      const r = await fetch('/api/events-history', { cache: 'no-cache' });
+      if (!r.ok) return; const events = await r.json(); if (!Array.isArray(events)) return;
+      historyList.innerHTML = ''; if (historyTitle) historyTitle.hidden = events.length === 0;
+      for (const evt of events.slice().reverse()) {
+        const c = Array.isArray(evt.changed) ? evt.changed : []; const i = Array.isArray(evt.impacted) ? evt.impacted : [];
+        const chip = document.createElement('span'); chip.className = 'chip';
+        chip.textContent = `${new Date(evt.ts).toLocaleTimeString()} · ${c.length} changed, ${i.length} impacted`; chip.title = c.map(relPath).join('\n');
+        chip.addEventListener('click', () => {
+          historyList.querySelectorAll('.chip').forEach(x => x.classList.remove('active')); chip.classList.add('active');
+          renderDiff(c, i); const keep = new Set([...c, ...i]); if (keep.size) applyFocus(keep);
+        });
+        historyList.appendChild(chip);
+      }
+    } catch (e) { console.error('history error', e); }
+  }
+
   function renderDiff(changed, impacted) {
     const c = Array.isArray(changed) ? changed : [];
     const i = Array.isArray(impacted) ? impacted : [];
@@ -1,42 +1,72 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/philjestin/philtographer/internal/glob"
 	"github.com/philjestin/philtographer/internal/graph"
 	"github.com/philjestin/philtographer/internal/scan"
-	"github.com/philjestin/philtographer/internal/scan/providers"
 	"github.com/philjestin/philtographer/internal/tsgraph"
 )
 
 var (
 	watchMode         string // "scan" or "components"
-	watchGraph        string // file to write graph json
+	watchGraph        string // file to write the graph to, in --format
 	watchEvents       string // file to write events json (changed + impacted)
 	watchAffectedOnly bool   // if true, write only affected subgraph to --graph after changes
 	watchPollInterval string // polling interval; if set, use polling instead of fsnotify (e.g., "2s")
 	watchIncludeDeps  bool   // if true, include forward transitive deps from importer seeds
+	watchMaxWatches   int    // directory watch limit; 0 = the OS inotify limit when known
+	watchPollOnLimit  bool   // if true, switch to polling instead of failing when the limit is hit
+	watchHTTP         string // address for the rebuild HTTP endpoint (e.g. ":9000"); empty = disabled
+	watchHistory      string // JSON lines file events are appended to; empty = disabled
+	watchHistoryMax   int    // entries kept in watchHistory
+	watchStdinFiles   bool   // if true, read changed paths from stdin instead of watching the filesystem
+	watchServe        string // address to serve the UI on from this process (e.g. ":8080"); empty = disabled
 )
 
 // watchCmd watches the workspace and rebuilds the graph on changes, emitting impacted sets.
 var watchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "Watch source files, rebuild the graph, and emit impacted nodes",
+	// Limit errors are operational, not usage mistakes.
+	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if watchGraph == "" {
-			return fmt.Errorf("--graph is required (output graph.json path)")
+			return fmt.Errorf("--graph is required (output graph path)")
+		}
+		if watchStdinFiles && strings.TrimSpace(watchPollInterval) != "" {
+			return fmt.Errorf("--stdin-files and --poll are mutually exclusive")
+		}
+		switch outFormat {
+		case "", "json", "yaml", "toml", "ndjson", "dot":
+		default:
+			return fmt.Errorf("unknown --format %q (want json, yaml, toml, ndjson, or dot)", outFormat)
+		}
+		if watchServe != "" && outFormat != "" && outFormat != "json" {
+			return fmt.Errorf("--serve needs --format json (the UI reads the graph as JSON)")
 		}
 		// Assemble config
 		var cfg scan.Config
@@ -57,31 +87,15 @@ var watchCmd = &cobra.Command{
 			switch watchMode {
 			case "components":
 				// collect entry paths similar to components command
-				var provs []providers.Provider
-				for _, spec := range cfg.Entries {
-					switch spec.Type {
-					case "rootsTs":
-						provs = append(provs, providers.RootsTsProvider{File: spec.File, NameFrom: spec.NameFrom})
-					case "explicit":
-						provs = append(provs, providers.ExplicitProvider{Name: spec.Name, Path: spec.Path})
-					default:
-						return nil, nil, fmt.Errorf("unknown entry provider type: %s", spec.Type)
-					}
+				provs, err := buildProviders(cfg.Entries)
+				if err != nil {
+					return nil, nil, err
 				}
-				seen := map[string]bool{}
-				var entryPaths []string
-				for _, p := range provs {
-					es, err := p.Discover(ctx, cfg.Root)
-					if err != nil {
-						return nil, nil, err
-					}
-					for _, e := range es {
-						if !seen[e.Path] {
-							seen[e.Path] = true
-							entryPaths = append(entryPaths, e.Path)
-						}
-					}
+				entries, err := discoverEntries(ctx, cfg.Root, provs, cfg.StrictEntries)
+				if err != nil {
+					return nil, nil, err
 				}
+				entryPaths := entryPaths(entries)
 				if len(entryPaths) == 0 {
 					// fallback: try root/index.*
 					rp := cfg.Root
@@ -110,14 +124,78 @@ var watchCmd = &cobra.Command{
 			}
 		}
 
+		// Rebuilds come from the debouncer and the HTTP endpoint; run one at a time.
+		var rebuildMu sync.Mutex
+		rebuild := func(files []string, affectedOnly bool) ([]string, error) {
+			rebuildMu.Lock()
+			defer rebuildMu.Unlock()
+			impacted, err := doRebuild(cfg.Root, build, watchGraph, watchEvents, files, affectedOnly)
+			// With --serve, clients hear about the new graph straight from here
+			// rather than through a watch on the files just written.
+			if watchServe != "" {
+				notifyClients()
+			}
+			return impacted, err
+		}
+
 		// initial build (write full graph)
-		if err := doRebuild(cfg.Root, build, watchGraph, watchEvents, nil, false); err != nil {
+		if _, err := rebuild(nil, false); err != nil {
 			return err
 		}
 
+		if watchServe != "" {
+			graphs, err := parseUIGraphs([]string{watchGraph})
+			if err != nil {
+				return err
+			}
+			ln, err := net.Listen("tcp", watchServe)
+			if err != nil {
+				return fmt.Errorf("--serve: %w", err)
+			}
+			logger.Info("UI listening", "url", "http://"+ln.Addr().String(), "graph", watchGraph, "events", watchEvents)
+			go func() {
+				if err := http.Serve(ln, newUIHandler(graphs, watchEvents, watchHistory)); err != nil {
+					logger.Error("UI server stopped", "err", err)
+				}
+			}()
+		}
+
+		if watchHTTP != "" {
+			// Listen up front so a bad or busy address fails the command.
+			ln, err := net.Listen("tcp", watchHTTP)
+			if err != nil {
+				return fmt.Errorf("--http: %w", err)
+			}
+			logger.Info("rebuild endpoint listening", "url", "http://"+ln.Addr().String()+"/rebuild")
+			go func() {
+				if err := http.Serve(ln, rebuildHandler(cfg.Root, rebuild)); err != nil {
+					logger.Error("rebuild endpoint stopped", "err", err)
+				}
+			}()
+		}
+
+		interval := 2 * time.Second
+		if strings.TrimSpace(watchPollInterval) != "" {
+			d, err := time.ParseDuration(watchPollInterval)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --poll interval %q (want e.g. 2s)", watchPollInterval)
+			}
+			interval = d
+		}
+
+		// changes from fsnotify or polling are debounced into a single rebuild
+		deb := newDebouncer(300*time.Millisecond, func(files []string) {
+			_, _ = rebuild(files, watchAffectedOnly)
+		})
+
+		// An external change feed replaces filesystem watching altogether
+		if watchStdinFiles {
+			return stdinLoop(os.Stdin, cfg.Root, deb)
+		}
+
 		// If polling requested explicitly, use it
 		if strings.TrimSpace(watchPollInterval) != "" {
-			return pollLoop(cfg.Root, build, watchGraph, watchEvents)
+			return pollLoop(cfg.Root, interval, deb)
 		}
 
 		// watcher setup (fsnotify)
@@ -128,33 +206,34 @@ var watchCmd = &cobra.Command{
 		defer watcher.Close()
 
 		// add directories recursively, including tsconfig alias target dirs
-		if err := addRecursive(watcher, cfg.Root); err != nil {
+		limit := watchMaxWatches
+		if limit == 0 {
+			limit = osWatchLimit()
+		}
+		watches := &watchSet{w: watcher, limit: limit}
+		if err := watches.addRecursive(cfg.Root); err != nil {
 			// If we hit EMFILE (too many open files), fall back to polling
 			if strings.Contains(strings.ToLower(err.Error()), "too many open files") {
-				fmt.Fprintln(os.Stderr, "[watch] too many watchers; falling back to polling")
-				return pollLoop(cfg.Root, build, watchGraph, watchEvents)
+				logger.Warn("too many watchers; falling back to polling")
+				return pollLoop(cfg.Root, interval, deb)
+			}
+			if errors.Is(err, errWatchLimit) {
+				if watchPollOnLimit {
+					logger.Warn("falling back to polling", "err", err)
+					return pollLoop(cfg.Root, interval, deb)
+				}
+				return fmt.Errorf("%w; raise fs.inotify.max_user_watches, narrow --root, or use --poll/--poll-on-limit", err)
 			}
 			return err
 		}
-		// include tsconfig paths watch roots to catch alias-only edits
-		aliasDirs := scan.NewResolver(cfg.Root).WatchDirs()
+		// include tsconfig paths watch roots (and baseDirs) to catch alias-only edits
+		aliasResolver := scan.NewResolver(cfg.Root)
+		aliasResolver.BaseDirs = cfg.BaseDirs
+		aliasDirs := aliasResolver.WatchDirs()
 		for _, d := range aliasDirs {
-			_ = watcher.Add(d)
-		}
-
-		// debounce changes
-		var mu sync.Mutex
-		pending := map[string]struct{}{}
-		var timer *time.Timer
-		flush := func() {
-			mu.Lock()
-			files := make([]string, 0, len(pending))
-			for f := range pending {
-				files = append(files, f)
+			if err := watches.add(d); errors.Is(err, errWatchLimit) {
+				logger.Warn("alias dir not watched", "dir", d, "err", err)
 			}
-			pending = map[string]struct{}{}
-			mu.Unlock()
-			_ = doRebuild(cfg.Root, build, watchGraph, watchEvents, files, watchAffectedOnly)
 		}
 
 		for {
@@ -166,28 +245,18 @@ var watchCmd = &cobra.Command{
 				// track new directories
 				if ev.Op&fsnotify.Create == fsnotify.Create {
 					if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
-						_ = addRecursive(watcher, ev.Name)
+						if err := watches.addRecursive(ev.Name); errors.Is(err, errWatchLimit) {
+							logger.Warn("changes will be missed", "dir", ev.Name, "err", err)
+						}
 						continue
 					}
 				}
 				// only care about file changes with code extensions
 				if isWatchedFile(ev.Name) {
-					mu.Lock()
-					p := ev.Name
-					if !filepath.IsAbs(p) {
-						if a, err := filepath.Abs(p); err == nil {
-							p = a
-						}
-					}
-					pending[filepath.Clean(p)] = struct{}{}
-					if timer != nil {
-						timer.Stop()
-					}
-					timer = time.AfterFunc(300*time.Millisecond, flush)
-					mu.Unlock()
+					deb.add(ev.Name)
 				}
 			case err := <-watcher.Errors:
-				fmt.Fprintln(os.Stderr, "watch error:", err)
+				logger.Error("watch error", "err", err)
 			}
 		}
 	},
@@ -198,8 +267,45 @@ func isWatchedFile(p string) bool {
 	return strings.HasSuffix(l, ".ts") || strings.HasSuffix(l, ".tsx") || strings.HasSuffix(l, ".js") || strings.HasSuffix(l, ".jsx") || strings.HasSuffix(l, ".d.ts")
 }
 
-func addRecursive(w *fsnotify.Watcher, root string) error {
-	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+// errWatchLimit is returned once adding another directory watch would exceed the limit.
+var errWatchLimit = errors.New("directory watch limit reached")
+
+// watchSet adds directory watches to w, counting them against limit (0 = unlimited)
+// and warning once the count gets close to it.
+type watchSet struct {
+	w      *fsnotify.Watcher
+	limit  int
+	added  atomic.Int64
+	warned atomic.Bool
+}
+
+// add watches dir. Per-directory failures are ignored, except ones that mean the
+// OS has run out of watches, which are reported as errWatchLimit.
+func (s *watchSet) add(dir string) error {
+	if s.limit > 0 && int(s.added.Load()) >= s.limit {
+		return fmt.Errorf("%w (%d of %d)", errWatchLimit, s.added.Load(), s.limit)
+	}
+	if err := s.w.Add(dir); err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			return fmt.Errorf("%w (OS refused after %d): %v", errWatchLimit, s.added.Load(), err)
+		}
+		if errors.Is(err, syscall.EMFILE) {
+			return err
+		}
+		return nil
+	}
+	n := s.added.Add(1)
+	if s.limit > 0 && n >= int64(s.limit)*9/10 && s.warned.CompareAndSwap(false, true) {
+		logger.Warn("approaching the directory watch limit", "watches", n, "limit", s.limit)
+	}
+	return nil
+}
+
+// addRecursive collects the directories under root and adds them in parallel,
+// stopping at the first limit error.
+func (s *watchSet) addRecursive(root string) error {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -211,21 +317,66 @@ func addRecursive(w *fsnotify.Watcher, root string) error {
 				}
 				return nil
 			}
-			_ = w.Add(path)
+			dirs = append(dirs, path)
 		}
 		return nil
 	})
+
+	jobs := make(chan string)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		stop     = make(chan struct{})
+	)
+	workers := min(runtime.NumCPU(), 8)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for dir := range jobs {
+				if err := s.add(dir); err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(stop)
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for _, dir := range dirs {
+		select {
+		case jobs <- dir:
+		case <-stop:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
 }
 
-// filterSubgraph returns a JSON-serializable view of only nodes in keep and edges among them.
-func filterSubgraph(g *graph.Graph, keep map[string]bool) interface{} {
-	// Collect nodes
-	nodes := []string{}
+// osWatchLimit returns the per-user inotify watch limit, or 0 when it can't be read.
+func osWatchLimit() int {
+	b, err := os.ReadFile("/proc/sys/fs/inotify/max_user_watches")
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// filterSubgraph returns the subgraph of only nodes in keep and edges among them,
+// with simple barrel paths flattened into direct edges.
+func filterSubgraph(g *graph.Graph, keep map[string]bool) *graph.Graph {
+	sub := graph.New()
 	for n := range keep {
-		nodes = append(nodes, n)
+		sub.Touch(n)
 	}
-	type edge struct{ From, To string }
-	edges := []edge{}
 	// Build a set for deduplication and a simple adjacency for 2-hop flattening
 	edgeSet := map[string]map[string]bool{}
 	g.ForEachEdge(func(from, to string) {
@@ -233,10 +384,8 @@ func filterSubgraph(g *graph.Graph, keep map[string]bool) interface{} {
 			if _, ok := edgeSet[from]; !ok {
 				edgeSet[from] = map[string]bool{}
 			}
-			if !edgeSet[from][to] {
-				edgeSet[from][to] = true
-				edges = append(edges, edge{From: from, To: to})
-			}
+			edgeSet[from][to] = true
+			sub.AddEdge(from, to)
 		}
 	})
 
@@ -244,35 +393,68 @@ func filterSubgraph(g *graph.Graph, keep map[string]bool) interface{} {
 	// for each from->mid and mid->to (all within keep), add a synthetic from->to edge.
 	for from, mids := range edgeSet {
 		for mid := range mids {
-			tos, ok := edgeSet[mid]
-			if !ok {
-				continue
-			}
-			for to := range tos {
-				if from == to {
-					continue
-				}
-				if _, ok := edgeSet[from]; !ok {
-					edgeSet[from] = map[string]bool{}
+			for to := range edgeSet[mid] {
+				if from != to {
+					sub.AddEdge(from, to)
 				}
-				if edgeSet[from][to] {
-					continue
-				}
-				edgeSet[from][to] = true
-				edges = append(edges, edge{From: from, To: to})
 			}
 		}
 	}
-	return struct {
-		Nodes []string `json:"nodes"`
-		Edges []edge   `json:"edges"`
-	}{Nodes: nodes, Edges: edges}
+	return sub
+}
+
+// rebuildRequest is the optional JSON body of POST /rebuild.
+type rebuildRequest struct {
+	Changed []string `json:"changed"`
+}
+
+// rebuildHandler serves POST /rebuild: it runs a rebuild right away (bypassing the
+// debouncer) for the changed files in the body, relative paths taken from root,
+// and responds with the impacted set. An empty body rebuilds with no changes.
+func rebuildHandler(root string, rebuild func([]string, bool) ([]string, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rebuild", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req rebuildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		changed := make([]string, 0, len(req.Changed))
+		for _, c := range req.Changed {
+			if !filepath.IsAbs(c) {
+				c = filepath.Join(root, c)
+			}
+			changed = append(changed, filepath.Clean(c))
+		}
+		sort.Strings(changed)
+		impacted, err := rebuild(changed, watchAffectedOnly)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if impacted == nil {
+			impacted = []string{}
+		}
+		sort.Strings(impacted)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Changed  []string `json:"changed"`
+			Impacted []string `json:"impacted"`
+		}{Changed: changed, Impacted: impacted})
+	})
+	return mux
 }
 
-func doRebuild(root string, build func(context.Context, []string) (*graph.Graph, []string, error), outGraph, outEvents string, changed []string, affectedOnly bool) error {
+// doRebuild builds the graph, writes it and the events file, and returns the impacted set.
+func doRebuild(root string, build func(context.Context, []string) (*graph.Graph, []string, error), outGraph, outEvents string, changed []string, affectedOnly bool) ([]string, error) {
 	g, impacted, err := build(context.Background(), changed)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "build error:", err)
+		logger.Error("build failed", "err", err)
 	}
 	if g != nil {
 		// If requested, write only the subgraph for changed+impacted (after changes).
@@ -285,31 +467,66 @@ func doRebuild(root string, build func(context.Context, []string) (*graph.Graph,
 				keep[filepath.Clean(i)] = true
 			}
 			sg := filterSubgraph(g, keep)
-			if err := writeJSONFile(outGraph, sg); err != nil {
-				fmt.Fprintln(os.Stderr, "write graph:", err)
+			if err := writeGraphFile(outGraph, sg); err != nil {
+				logger.Error("write graph", "err", err)
 			} else {
-				fmt.Fprintf(os.Stderr, "[watch] wrote affected graph: changed=%d impacted=%d\n", len(changed), len(impacted))
+				logger.Info("wrote affected graph", "changed", len(changed), "impacted", len(impacted))
 			}
 		} else {
-			if err := writeJSONFile(outGraph, g); err != nil {
-				fmt.Fprintln(os.Stderr, "write graph:", err)
+			if err := writeGraphFile(outGraph, g); err != nil {
+				logger.Error("write graph", "err", err)
 			} else {
-				fmt.Fprintf(os.Stderr, "[watch] wrote full graph: nodes=%d\n", len(g.Nodes()))
+				logger.Info("wrote full graph", "nodes", len(g.Nodes()))
 			}
 		}
 	}
 	// write events JSON even if graph failed; impacted may be empty
-	evt := struct {
-		Timestamp int64    `json:"ts"`
-		Changed   []string `json:"changed"`
-		Impacted  []string `json:"impacted"`
-	}{Timestamp: time.Now().UnixMilli(), Changed: changed, Impacted: impacted}
+	evt := watchEvent{Timestamp: time.Now().UnixMilli(), Changed: changed, Impacted: impacted}
 	if err := writeJSONFile(outEvents, evt); err != nil {
-		fmt.Fprintln(os.Stderr, "write events:", err)
+		logger.Error("write events", "err", err)
 	} else {
-		fmt.Fprintf(os.Stderr, "[watch] events updated (changed=%d impacted=%d)\n", len(changed), len(impacted))
+		logger.Info("events updated", "changed", len(changed), "impacted", len(impacted))
 	}
-	return nil
+	if watchHistory != "" {
+		if err := appendHistory(watchHistory, evt, watchHistoryMax); err != nil {
+			logger.Error("write history", "err", err)
+		}
+	}
+	return impacted, nil
+}
+
+// watchEvent is one rebuild as written to events.json and the history file.
+type watchEvent struct {
+	Timestamp int64    `json:"ts"`
+	Changed   []string `json:"changed"`
+	Impacted  []string `json:"impacted"`
+}
+
+// appendHistory adds evt as a JSON line to path, dropping the oldest lines so at
+// most max remain (max <= 0 keeps everything). The file is rewritten in place.
+func appendHistory(path string, evt watchEvent, max int) error {
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	var lines [][]byte
+	if data, err := os.ReadFile(path); err == nil {
+		for _, l := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(l)) > 0 {
+				lines = append(lines, l)
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	lines = append(lines, line)
+	if max > 0 && len(lines) > max {
+		lines = lines[len(lines)-max:]
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0o644)
 }
 
 func impactedForChanges(root string, g *graph.Graph, changed []string) []string {
@@ -320,19 +537,7 @@ func impactedForChanges(root string, g *graph.Graph, changed []string) []string
 	out := []string{}
 	// Build a quick set of node keys for fallback matching
 	nodes := g.Nodes()
-	for _, c := range changed {
-		// normalize to absolute, then to cleaned path used in nodes
-		if !filepath.IsAbs(c) {
-			if a, err := filepath.Abs(filepath.Join(root, c)); err == nil {
-				c = a
-			}
-		}
-		// resolve symlinks if possible to match how nodes were recorded
-		if real, err := filepath.EvalSymlinks(c); err == nil {
-			c = real
-		}
-		c = filepath.Clean(c)
-
+	for _, c := range changedNodes(root, g, changed) {
 		// Seed with direct importers (incoming edges)
 		impacted := g.InNeighbors(c)
 		// Also include the changed file's immediate outgoing deps (to capture barrel targets)
@@ -425,39 +630,157 @@ func impactedForChanges(root string, g *graph.Graph, changed []string) []string
 	return out
 }
 
-func writeJSONFile(path string, v interface{}) error {
+// changedNodes expands glob patterns in changed against the graph's nodes and
+// normalizes plain paths the way nodes were recorded (absolute, symlinks resolved).
+func changedNodes(root string, g *graph.Graph, changed []string) []string {
+	out := []string{}
+	for _, c := range changed {
+		if glob.IsPattern(c) {
+			out = append(out, matchNodes(root, g, c)...)
+			continue
+		}
+		// already a node key (e.g. graphs recorded with relative paths)
+		if clean := filepath.Clean(c); g.Has(clean) {
+			out = append(out, clean)
+			continue
+		}
+		// normalize to absolute, then to cleaned path used in nodes
+		if !filepath.IsAbs(c) {
+			if a, err := filepath.Abs(filepath.Join(root, c)); err == nil {
+				c = a
+			}
+		}
+		// resolve symlinks if possible to match how nodes were recorded
+		if real, err := filepath.EvalSymlinks(c); err == nil {
+			c = real
+		}
+		out = append(out, filepath.Clean(c))
+	}
+	return out
+}
+
+// resolveNodeArg maps a --node value to exactly one node of g: the node as
+// written, a path relative to root, or a glob matching a single node. A literal
+// node is checked first, so names like pages/[id].tsx aren't taken for globs.
+func resolveNodeArg(root string, g *graph.Graph, arg, graphPath string) (string, error) {
+	if clean := filepath.Clean(arg); g.Has(clean) {
+		return clean, nil
+	}
+	nodes := changedNodes(root, g, []string{arg})
+	switch {
+	case len(nodes) > 1:
+		return "", fmt.Errorf("%s matches %d nodes in %s; narrow it down to one", arg, len(nodes), graphPath)
+	case len(nodes) == 1 && g.Has(nodes[0]):
+		return nodes[0], nil
+	}
+	return "", fmt.Errorf("%s is not a node in %s", arg, graphPath)
+}
+
+// matchNodes returns graph nodes matching pattern, either as written or
+// anchored at root when the pattern is relative.
+func matchNodes(root string, g *graph.Graph, pattern string) []string {
+	anchored := pattern
+	if !filepath.IsAbs(pattern) {
+		if absRoot, err := filepath.Abs(root); err == nil {
+			anchored = filepath.ToSlash(filepath.Join(absRoot, pattern))
+		}
+	}
+	var out []string
+	for _, n := range g.Nodes() {
+		if glob.Match(pattern, n) || glob.Match(anchored, n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// writeGraphFile writes g to path in the selected --format, creating its directory.
+// The file is replaced atomically, so a UI reading it never sees half a graph.
+func writeGraphFile(path string, g *graph.Graph) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	f, err := os.Create(path)
-	if err != nil {
+	return replaceFile(path, func(w io.Writer) error { return encodeGraph(w, g) })
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	return enc.Encode(v)
+	return replaceFile(path, func(w io.Writer) error { return newJSONEncoder(w).Encode(v) })
 }
 
-// Polling fallback loop. Scans mtimes of source files at interval and triggers rebuilds when they change.
-func pollLoop(root string, build func(context.Context, []string) (*graph.Graph, []string, error), outGraph, outEvents string) error {
-	// parse interval
-	interval := 2 * time.Second
-	if strings.TrimSpace(watchPollInterval) != "" {
-		if d, err := time.ParseDuration(watchPollInterval); err == nil {
-			interval = d
+// debouncer collects changed paths and calls flush once no new change has
+// arrived for delay, so bursts of edits trigger a single rebuild.
+type debouncer struct {
+	mu      sync.Mutex
+	pending map[string]struct{}
+	timer   *time.Timer
+	delay   time.Duration
+	flush   func(files []string)
+}
+
+func newDebouncer(delay time.Duration, flush func(files []string)) *debouncer {
+	return &debouncer{pending: map[string]struct{}{}, delay: delay, flush: flush}
+}
+
+// add records p (made absolute) as changed and restarts the timer.
+func (d *debouncer) add(p string) {
+	if !filepath.IsAbs(p) {
+		if a, err := filepath.Abs(p); err == nil {
+			p = a
 		}
 	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[filepath.Clean(p)] = struct{}{}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(d.delay, d.fire)
+}
+
+// drain flushes pending changes now instead of waiting for the timer.
+func (d *debouncer) drain() {
+	d.mu.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	empty := len(d.pending) == 0
+	d.mu.Unlock()
+	if !empty {
+		d.fire()
+	}
+}
+
+func (d *debouncer) fire() {
+	d.mu.Lock()
+	files := make([]string, 0, len(d.pending))
+	for f := range d.pending {
+		files = append(files, f)
+	}
+	d.pending = map[string]struct{}{}
+	d.mu.Unlock()
+	sort.Strings(files)
+	d.flush(files)
+}
+
+// Polling fallback loop for filesystems where fsnotify delivers no events (NFS,
+// some container mounts). Re-stats source files every interval and feeds added,
+// modified, and removed files into the same debounced rebuild as fsnotify.
+func pollLoop(root string, interval time.Duration, deb *debouncer) error {
+	logger.Info("polling for changes", "every", interval)
 	mtimes := map[string]time.Time{}
-	snapshot := func(recordChanges bool) []string {
+	snapshot := func() []string {
 		changed := []string{}
+		seen := make(map[string]struct{}, len(mtimes))
 		filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 			if err != nil {
 				return nil
 			}
 			if d.IsDir() {
 				name := d.Name()
-				if strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist" || name == "build" {
+				if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist" || name == "build") {
 					return filepath.SkipDir
 				}
 				return nil
@@ -465,37 +788,72 @@ func pollLoop(root string, build func(context.Context, []string) (*graph.Graph,
 			if !isWatchedFile(path) {
 				return nil
 			}
-			if info, err := os.Stat(path); err == nil {
-				if prev, ok := mtimes[path]; !ok || info.ModTime().After(prev) {
-					if recordChanges && ok {
-						changed = append(changed, path)
-					}
-					mtimes[path] = info.ModTime()
-				}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			seen[path] = struct{}{}
+			if prev, ok := mtimes[path]; !ok || !info.ModTime().Equal(prev) {
+				changed = append(changed, path)
+				mtimes[path] = info.ModTime()
 			}
 			return nil
 		})
+		for path := range mtimes {
+			if _, ok := seen[path]; !ok {
+				changed = append(changed, path)
+				delete(mtimes, path)
+			}
+		}
 		return changed
 	}
-	// Prime the snapshot without recording changes
-	_ = snapshot(false)
+	// Prime the snapshot; the initial build already covers these files
+	_ = snapshot()
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	for {
-		<-ticker.C
-		changed := snapshot(true)
-		if len(changed) > 0 {
-			_ = doRebuild(root, build, outGraph, outEvents, changed, watchAffectedOnly)
+	for range ticker.C {
+		for _, p := range snapshot() {
+			deb.add(p)
+		}
+	}
+	return nil
+}
+
+// stdinLoop feeds newline-delimited changed paths from r (a build system's change
+// notifier, watchman, ...) into the debounced rebuild. Relative paths are taken
+// relative to root, and lines that aren't source files are ignored. At EOF any
+// pending changes are rebuilt before returning.
+func stdinLoop(r io.Reader, root string, deb *debouncer) error {
+	logger.Info("reading changed files from stdin")
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		p := strings.TrimSpace(sc.Text())
+		if p == "" || !isWatchedFile(p) {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
 		}
+		deb.add(p)
 	}
+	deb.drain()
+	return sc.Err()
 }
 
 func init() {
 	rootCmd.AddCommand(watchCmd)
 	watchCmd.Flags().StringVar(&watchMode, "mode", "scan", "build mode: scan|components")
-	watchCmd.Flags().StringVar(&watchGraph, "graph", "", "output graph.json path")
+	watchCmd.Flags().StringVar(&watchGraph, "graph", "", "output graph path (graph.json, or e.g. graph.dot with --format dot)")
 	watchCmd.Flags().StringVar(&watchEvents, "events", "", "output events.json path (default: sibling of --graph)")
 	watchCmd.Flags().BoolVar(&watchAffectedOnly, "affected-only", false, "write only affected subgraph to --graph after each change")
 	watchCmd.Flags().StringVar(&watchPollInterval, "poll", "", "polling interval (e.g., '2s'); if set, uses polling instead of fsnotify")
+	watchCmd.Flags().IntVar(&watchMaxWatches, "max-watches", 0, "maximum directory watches to add (0 = OS inotify limit when known)")
+	watchCmd.Flags().BoolVar(&watchPollOnLimit, "poll-on-limit", false, "switch to polling instead of failing when the watch limit is reached")
+	addOutputFlags(watchCmd)
+	watchCmd.Flags().StringVar(&watchHistory, "history", "", "also append each event to this JSON lines file (a rolling timeline for ui --history)")
+	watchCmd.Flags().IntVar(&watchHistoryMax, "history-max", 200, "number of events kept in --history (0 = unlimited)")
+	watchCmd.Flags().BoolVar(&watchStdinFiles, "stdin-files", false, "read changed file paths (one per line, relative to --root or absolute) from stdin instead of watching")
+	watchCmd.Flags().StringVar(&watchServe, "serve", "", "also serve the UI for --graph on this address (e.g. ':8080'), pushing each rebuild to connected clients")
+	watchCmd.Flags().StringVar(&watchHTTP, "http", "", "serve POST /rebuild on this address (e.g. ':9000') to trigger rebuilds")
 	watchCmd.Flags().BoolVar(&watchIncludeDeps, "include-deps", false, "include forward transitive dependencies from importer seeds in impacted set")
 }
@@ -0,0 +1,92 @@
+// Package glob matches slash-separated paths against shell patterns, adding
+// support for "**" segments that match any number of directories.
+package glob
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsPattern reports whether s contains glob metacharacters.
+func IsPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// Match reports whether name matches pattern. Both are treated as slash-separated;
+// OS-specific separators in name are normalized first. A "**" segment matches zero
+// or more whole path segments; other segments follow path.Match.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for len(pat) > 0 && pat[0] == "**" {
+				pat = pat[1:]
+			}
+			if len(pat) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Expand walks the filesystem and returns paths (files and directories) matching
+// pattern, which is interpreted relative to root unless absolute. The walk starts at
+// the pattern's longest literal prefix and skips dot directories and node_modules.
+// Results are in lexical order.
+func Expand(root, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(root, pattern)
+	}
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	// walk from the literal directory prefix to avoid scanning unrelated trees
+	segs := strings.Split(pattern, "/")
+	i := 0
+	for i < len(segs)-1 && !IsPattern(segs[i]) {
+		i++
+	}
+	base := filepath.FromSlash(strings.Join(segs[:i], "/"))
+	if base == "" {
+		base = "."
+		if strings.HasPrefix(pattern, "/") {
+			base = "/"
+		}
+	}
+
+	var out []string
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && p != base {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || name == "node_modules" {
+				return filepath.SkipDir
+			}
+		}
+		if Match(pattern, p) {
+			out = append(out, p)
+		}
+		return nil
+	})
+	return out, err
+}
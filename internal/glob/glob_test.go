@@ -0,0 +1,24 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"src/shared/**", "src/shared/a.ts", true},
+		{"src/shared/**", "src/shared/deep/b.tsx", true},
+		{"src/shared/**", "src/other/a.ts", false},
+		{"**/*.stories.tsx", "src/Button.stories.tsx", true},
+		{"**/*.stories.tsx", "Button.stories.tsx", true},
+		{"src/*.ts", "src/deep/a.ts", false},
+		{"@acme/*", "@acme/ui", true},
+		{"/abs/**/x.ts", "/abs/a/b/x.ts", true},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.name); got != c.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package graph
+
+// CondensedPrefix starts the name of a node standing for a strongly connected
+// component of several nodes in a Condensation: "scc:" plus the component's first
+// node.
+const CondensedPrefix = "scc:"
+
+// Condensation returns the graph with each strongly connected component collapsed
+// into one node, which is always a DAG, and a map from every original node to the
+// node it became. Nodes outside any cycle keep their name and attrs; a component of
+// several nodes becomes CondensedPrefix + its first node (see SCC), with the sizes
+// of its members summed. Edges between components keep the specs of the edges they
+// stand for, and their kind when those edges all agree; edges inside a component
+// are dropped.
+func (g *Graph) Condensation() (*Graph, map[string]string) {
+	sccs, comp := g.sccIndex()
+	names := make([]string, len(sccs))
+	for i, c := range sccs {
+		names[i] = c[0]
+		if len(c) > 1 {
+			names[i] = CondensedPrefix + c[0]
+		}
+	}
+
+	out := New()
+	mapping := make(map[string]string, len(comp))
+	for i, c := range sccs {
+		out.Touch(names[i])
+		var sum NodeAttrs
+		found := false
+		for _, n := range c {
+			mapping[n] = names[i]
+			if a, ok := g.attrs[n]; ok {
+				found = true
+				sum.Bytes += a.Bytes
+				sum.Lines += a.Lines
+				sum.Boundary = sum.Boundary || a.Boundary
+			}
+		}
+		if found {
+			out.attrs[names[i]] = sum
+		}
+	}
+
+	type pair struct{ from, to string }
+	kinds := map[pair]string{}
+	mixed := map[pair]bool{}
+	g.ForEachEdge(func(from, to string) {
+		a, b := mapping[from], mapping[to]
+		if a == b {
+			return
+		}
+		out.AddEdge(a, b)
+		for _, spec := range g.Specs(from, to) {
+			out.AddEdgeSpec(a, b, spec)
+		}
+		p, k := pair{a, b}, g.EdgeKind(from, to)
+		if prev, ok := kinds[p]; ok && prev != k {
+			mixed[p] = true
+		}
+		kinds[p] = k
+	})
+	for p, k := range kinds {
+		if k != "" && !mixed[p] {
+			out.SetEdgeKind(p.from, p.to, k)
+		}
+	}
+	return out, mapping
+}
+
+// sccIndex returns the graph's strongly connected components (see SCC) and the
+// index of the component each node belongs to.
+func (g *Graph) sccIndex() ([][]string, map[string]int) {
+	comp := map[string]int{}
+	sccs := g.SCC()
+	for i, c := range sccs {
+		for _, n := range c {
+			comp[n] = i
+		}
+	}
+	return sccs, comp
+}
@@ -0,0 +1,113 @@
+package graph
+
+import "sort"
+
+// SCC returns the strongly connected components of the graph (Tarjan's algorithm).
+// Each component is sorted, and components are ordered by their first node, so the
+// result is deterministic. Every node appears in exactly one component.
+func (g *Graph) SCC() [][]string {
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	next := 0
+	var comps [][]string
+
+	var strongconnect func(v string)
+	strongconnect = func(v string) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.OutNeighbors(v) {
+			if _, seen := index[w]; !seen {
+				strongconnect(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+
+		// v is the root of a component: pop it off the stack
+		if low[v] == index[v] {
+			var comp []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp = append(comp, w)
+				if w == v {
+					break
+				}
+			}
+			sort.Strings(comp)
+			comps = append(comps, comp)
+		}
+	}
+
+	for _, n := range g.Nodes() {
+		if _, seen := index[n]; !seen {
+			strongconnect(n)
+		}
+	}
+	sort.Slice(comps, func(i, j int) bool { return comps[i][0] < comps[j][0] })
+	return comps
+}
+
+// Cycles returns one concrete cycle for every strongly connected component with more
+// than one node. Each cycle starts at the component's smallest node and lists the path
+// back to it (the start is not repeated at the end).
+func (g *Graph) Cycles() [][]string {
+	var out [][]string
+	for _, comp := range g.SCC() {
+		if len(comp) < 2 {
+			continue
+		}
+		in := make(map[string]bool, len(comp))
+		for _, n := range comp {
+			in[n] = true
+		}
+		if c := g.cycleThrough(comp[0], in); len(c) > 0 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// cycleThrough finds a shortest path from start back to start using only nodes in comp (BFS).
+func (g *Graph) cycleThrough(start string, comp map[string]bool) []string {
+	parent := map[string]string{}
+	queue := []string{start}
+	visited := map[string]bool{start: true}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, to := range g.OutNeighbors(n) {
+			if !comp[to] {
+				continue
+			}
+			if to == start {
+				// walk parents back to start
+				path := []string{n}
+				for p := n; p != start; {
+					p = parent[p]
+					path = append(path, p)
+				}
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if !visited[to] {
+				visited[to] = true
+				parent[to] = n
+				queue = append(queue, to)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteDOT writes g in Graphviz DOT format. Nodes are quoted by name; externals
+// are drawn as boxes, and labeled edges (e.g. EdgeImported) are dashed. Output is
+// sorted so diffs between runs stay small.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph philtographer {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	nodes := g.Nodes()
+	for _, n := range nodes {
+		if KindOf(n) == KindExternal {
+			fmt.Fprintf(bw, "  %s [shape=box];\n", strconv.Quote(n))
+		} else {
+			fmt.Fprintf(bw, "  %s;\n", strconv.Quote(n))
+		}
+	}
+	for _, from := range nodes {
+		for _, to := range g.OutNeighbors(from) {
+			attrs := ""
+			switch k := g.EdgeKind(from, to); k {
+			case "":
+			case EdgeImported:
+				attrs = fmt.Sprintf(" [label=%s, style=dashed]", strconv.Quote(k))
+			default:
+				attrs = fmt.Sprintf(" [label=%s]", strconv.Quote(k))
+			}
+			fmt.Fprintf(bw, "  %s -> %s%s;\n", strconv.Quote(from), strconv.Quote(to), attrs)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
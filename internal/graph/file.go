@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ImpactedFromFile loads a graph.json written by scan/entries/components and returns
+// the union of Impacted over changed, sorted. Changed paths are matched as written
+// when they are already nodes; otherwise they are made absolute and have symlinks
+// resolved, the way scans record nodes. Paths that aren't in the graph impact nothing.
+func ImpactedFromFile(graphPath string, changed []string) ([]string, error) {
+	data, err := os.ReadFile(graphPath)
+	if err != nil {
+		return nil, err
+	}
+	g := New()
+	if err := g.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", graphPath, err)
+	}
+	seen := map[string]bool{}
+	for _, c := range changed {
+		for _, n := range g.Impacted(g.normalizePath(c)) {
+			seen[n] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// normalizePath maps a file path onto the node key a scan would have recorded for it.
+func (g *Graph) normalizePath(p string) string {
+	p = filepath.Clean(p)
+	if g.Has(p) {
+		return p
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	if realPath, err := filepath.EvalSymlinks(p); err == nil {
+		p = realPath
+	}
+	return p
+}
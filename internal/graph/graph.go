@@ -12,15 +12,53 @@ type Graph struct {
 	// reverse[b] is a set of files that import B.
 	// we can compute lazily or via Add
 	reverse map[string]map[string]struct{}
+
+	// attrs holds optional per-node annotations (e.g. file size), keyed by node.
+	attrs map[string]NodeAttrs
+
+	// specs[a][b] is the set of import specifiers in A that resolved to B.
+	specs map[string]map[string]map[string]struct{}
+
+	// edgeKinds[a][b] labels the edge A -> B (e.g. EdgeRendered), when set.
+	edgeKinds map[string]map[string]string
+}
+
+// NodeAttrs are optional annotations for a node, emitted under "attrs" in JSON.
+type NodeAttrs struct {
+	Bytes int `json:"bytes,omitempty" yaml:"bytes,omitempty" toml:"bytes,omitempty"` // file size in bytes
+	Lines int `json:"lines,omitempty" yaml:"lines,omitempty" toml:"lines,omitempty"` // number of lines in the file
+	// Boundary marks a file outside a partial scan's walked subtree that was
+	// imported from inside it: its own imports were not followed.
+	Boundary bool `json:"boundary,omitempty" yaml:"boundary,omitempty" toml:"boundary,omitempty"`
 }
 
 func New() *Graph {
 	return &Graph{
 		edges:   make(map[string]map[string]struct{}),
 		reverse: make(map[string]map[string]struct{}),
+		attrs:   make(map[string]NodeAttrs),
+		specs:   make(map[string]map[string]map[string]struct{}),
 	}
 }
 
+// SetAttrs records annotations for node n (touching it if needed).
+func (g *Graph) SetAttrs(n string, a NodeAttrs) {
+	if n == "" {
+		return
+	}
+	g.Touch(n)
+	if g.attrs == nil {
+		g.attrs = make(map[string]NodeAttrs)
+	}
+	g.attrs[n] = a
+}
+
+// Attrs returns the annotations recorded for node n, if any.
+func (g *Graph) Attrs(n string) (NodeAttrs, bool) {
+	a, ok := g.attrs[n]
+	return a, ok
+}
+
 // This basically helps make sure that we can traverse the graph backwards
 // which helps for topological sort and dependency resolution (key)
 func (g *Graph) AddEdge(from, to string) {
@@ -52,6 +90,63 @@ func (g *Graph) AddEdge(from, to string) {
 	g.reverse[to][from] = struct{}{}
 }
 
+// AddEdgeSpec adds the edge from -> to and records spec as one of the specifiers
+// that produced it, so resolution mistakes can be traced back to the import line.
+func (g *Graph) AddEdgeSpec(from, to, spec string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	g.AddEdge(from, to)
+	if spec == "" {
+		return
+	}
+	if g.specs == nil {
+		g.specs = make(map[string]map[string]map[string]struct{})
+	}
+	if _, ok := g.specs[from]; !ok {
+		g.specs[from] = make(map[string]map[string]struct{})
+	}
+	if _, ok := g.specs[from][to]; !ok {
+		g.specs[from][to] = make(map[string]struct{})
+	}
+	g.specs[from][to][spec] = struct{}{}
+}
+
+// Specs returns the sorted specifiers recorded for the edge from -> to, if any.
+func (g *Graph) Specs(from, to string) []string {
+	set := g.specs[from][to]
+	if len(set) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SetEdgeKind adds the edge from -> to and labels it with kind, replacing any
+// previous label.
+func (g *Graph) SetEdgeKind(from, to, kind string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	g.AddEdge(from, to)
+	if g.edgeKinds == nil {
+		g.edgeKinds = make(map[string]map[string]string)
+	}
+	if _, ok := g.edgeKinds[from]; !ok {
+		g.edgeKinds[from] = make(map[string]string)
+	}
+	g.edgeKinds[from][to] = kind
+}
+
+// EdgeKind returns the label of the edge from -> to, or "" if it has none.
+func (g *Graph) EdgeKind(from, to string) string {
+	return g.edgeKinds[from][to]
+}
+
 // Collects all of the unique nodes in the graph, whether they appear as a source
 // or destination. Return them in a slice of strings, and ensures they are sorted.
 func (g *Graph) Nodes() []string {
@@ -119,32 +214,310 @@ func (g *Graph) Impacted(start string) []string {
 	return out
 }
 
-// Whenever we do json.Marshall(g), this method will be called
-// it must return json or an error
-func (g *Graph) MarshalJSON() ([]byte, error) {
-	// Create a tiny struct with two string fields, From and To
-	// this struct will represent each edge when serialized
-	type edge struct{ From, To string }
+// ImpactedDepth is Impacted limited to importers at most depth hops from start
+// (direct importers are 1 hop; depth <= 0 means no limit), returning each one's
+// distance: the fewest imports between it and start.
+func (g *Graph) ImpactedDepth(start string, depth int) map[string]int {
+	dist := map[string]int{}
+	frontier := []string{start}
+	for d := 1; len(frontier) > 0 && (depth <= 0 || d <= depth); d++ {
+		var next []string
+		for _, n := range frontier {
+			for pred := range g.reverse[n] {
+				if _, seen := dist[pred]; !seen && pred != start {
+					dist[pred] = d
+					next = append(next, pred)
+				}
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
+
+// Dependencies returns every node start directly or indirectly imports, excluding
+// start itself. It is the forward counterpart of Impacted. Sorted.
+func (g *Graph) Dependencies(start string) []string {
+	out := []string{}
+	for _, n := range g.Reachable(start) {
+		if n != start {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// DependenciesDepth is Dependencies limited to nodes at most depth hops from
+// start (direct imports are 1 hop; depth <= 0 means no limit), returning each
+// one's distance. It is the forward counterpart of ImpactedDepth.
+func (g *Graph) DependenciesDepth(start string, depth int) map[string]int {
+	dist := map[string]int{}
+	frontier := []string{start}
+	for d := 1; len(frontier) > 0 && (depth <= 0 || d <= depth); d++ {
+		var next []string
+		for _, n := range frontier {
+			for succ := range g.edges[n] {
+				if _, seen := dist[succ]; !seen && succ != start {
+					dist[succ] = d
+					next = append(next, succ)
+				}
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
+
+// CommonDependencies returns what a and b both import, directly or transitively:
+// the intersection of Dependencies(a) and Dependencies(b). Sorted.
+func (g *Graph) CommonDependencies(a, b string) []string {
+	inB := map[string]bool{}
+	for _, n := range g.Dependencies(b) {
+		inB[n] = true
+	}
+	out := []string{}
+	for _, n := range g.Dependencies(a) {
+		if inB[n] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Degrees returns the number of nodes that import n (in) and that n imports (out).
+func (g *Graph) Degrees(n string) (in, out int) {
+	return len(g.reverse[n]), len(g.edges[n])
+}
+
+// Reachable returns the forward closure of starts: the starts themselves (when they
+// are nodes) plus everything they directly or indirectly import. Sorted.
+func (g *Graph) Reachable(starts ...string) []string {
+	visited := map[string]bool{}
+	queue := []string{}
+	for _, s := range starts {
+		if g.Has(s) && !visited[s] {
+			visited[s] = true
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for to := range g.edges[n] {
+			if !visited[to] {
+				visited[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	out := make([]string, 0, len(visited))
+	for n := range visited {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
 
-	edges := []edge{}
+// EgoGraph returns the subgraph of everything within radius hops of node,
+// following edges in either direction (importers and imports alike), with the
+// edges among those nodes. It is empty when node isn't in the graph.
+func (g *Graph) EgoGraph(node string, radius int) *Graph {
+	if !g.Has(node) {
+		return New()
+	}
+	dist := map[string]int{node: 0}
+	queue := []string{node}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if dist[n] == radius {
+			continue
+		}
+		for _, next := range []map[string]struct{}{g.edges[n], g.reverse[n]} {
+			for m := range next {
+				if _, seen := dist[m]; !seen {
+					dist[m] = dist[n] + 1
+					queue = append(queue, m)
+				}
+			}
+		}
+	}
+	nodes := make([]string, 0, len(dist))
+	for n := range dist {
+		nodes = append(nodes, n)
+	}
+	return g.Subgraph(nodes)
+}
+
+// Subgraph returns a new graph containing only the given nodes, the edges among
+// them, and their attrs. Unknown nodes are ignored.
+func (g *Graph) Subgraph(nodes []string) *Graph {
+	keep := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if g.Has(n) {
+			keep[n] = true
+		}
+	}
+	sub := New()
+	for n := range keep {
+		sub.Touch(n)
+		if a, ok := g.attrs[n]; ok {
+			sub.attrs[n] = a
+		}
+		for to := range g.edges[n] {
+			if keep[to] {
+				g.copyEdge(sub, n, to)
+			}
+		}
+	}
+	return sub
+}
+
+// Inverted returns a copy of the graph with every edge reversed, so B -> A means
+// "B is depended on by A" instead of "A imports B". Specs, kinds, and attrs are
+// kept. The graph itself always stores imports; this is for output only.
+func (g *Graph) Inverted() *Graph {
+	out := New()
+	for _, n := range g.Nodes() {
+		out.Touch(n)
+		if a, ok := g.attrs[n]; ok {
+			out.attrs[n] = a
+		}
+	}
+	g.ForEachEdge(func(from, to string) {
+		out.AddEdge(to, from)
+		for _, spec := range g.Specs(from, to) {
+			out.AddEdgeSpec(to, from, spec)
+		}
+		if k := g.EdgeKind(from, to); k != "" {
+			out.SetEdgeKind(to, from, k)
+		}
+	})
+	return out
+}
+
+// Relabel returns a copy of the graph with every node n renamed to rename(n), in
+// edges, attrs, specs, and kinds alike. Nodes renamed to the same name are merged.
+func (g *Graph) Relabel(rename func(string) string) *Graph {
+	out := New()
+	for _, n := range g.Nodes() {
+		out.Touch(rename(n))
+		if a, ok := g.attrs[n]; ok {
+			out.attrs[rename(n)] = a
+		}
+	}
+	g.ForEachEdge(func(from, to string) {
+		f, t := rename(from), rename(to)
+		out.AddEdge(f, t)
+		for _, spec := range g.Specs(from, to) {
+			out.AddEdgeSpec(f, t, spec)
+		}
+		if k := g.EdgeKind(from, to); k != "" {
+			out.SetEdgeKind(f, t, k)
+		}
+	})
+	return out
+}
+
+// copyEdge adds the edge from -> to to dst along with its specs and kind.
+func (g *Graph) copyEdge(dst *Graph, from, to string) {
+	dst.AddEdge(from, to)
+	for _, spec := range g.Specs(from, to) {
+		dst.AddEdgeSpec(from, to, spec)
+	}
+	if k := g.EdgeKind(from, to); k != "" {
+		dst.SetEdgeKind(from, to, k)
+	}
+}
+
+// Document is the serialized form of a graph. Every output format (JSON, YAML, TOML)
+// encodes this same shape so node/edge semantics stay identical across formats.
+type Document struct {
+	Nodes []string             `json:"nodes" yaml:"nodes" toml:"nodes"`
+	Edges []Edge               `json:"edges" yaml:"edges" toml:"edges"`
+	Attrs map[string]NodeAttrs `json:"attrs,omitempty" yaml:"attrs,omitempty" toml:"attrs,omitempty"`
+	Kinds map[string]string    `json:"kinds,omitempty" yaml:"kinds,omitempty" toml:"kinds,omitempty"`
+}
+
+// Edge is a single directed edge in a Document: From imports To. Specs lists the
+// import specifiers in From that resolved to To, when known; Kind is the edge's
+// label, if it has one.
+type Edge struct {
+	From  string   `json:"From" yaml:"From" toml:"From"`
+	To    string   `json:"To" yaml:"To" toml:"To"`
+	Specs []string `json:"specs,omitempty" yaml:"specs,omitempty" toml:"specs,omitempty"`
+	Kind  string   `json:"kind,omitempty" yaml:"kind,omitempty" toml:"kind,omitempty"`
+}
+
+// Document returns the serializable view of g, edges sorted by From then To.
+func (g *Graph) Document() Document {
+	edges := []Edge{}
 
 	// iterates over the forward adjacency list. For each from node, loop through all its to nodes
 	// appends an edge into the edges slice
 	// now we have every directed edge in teh graph
 	for from, tos := range g.edges {
 		for to := range tos {
-			edges = append(edges, edge{From: from, To: to})
+			edges = append(edges, Edge{From: from, To: to, Specs: g.Specs(from, to), Kind: g.EdgeKind(from, to)})
 		}
 	}
 
-	// creates an anonymous struct with two fields.
-	return json.Marshal(struct {
-		Nodes []string `json:"nodes"`
-		Edges []edge   `json:"edges"`
-	}{
-		Nodes: g.Nodes(),
-		Edges: edges,
+	// sorted, so the same graph always serializes the same way
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
 	})
+
+	var attrs map[string]NodeAttrs
+	if len(g.attrs) > 0 {
+		attrs = g.attrs
+	}
+	return Document{Nodes: g.Nodes(), Edges: edges, Attrs: attrs, Kinds: g.NodeKinds()}
+}
+
+// Load adds the nodes, edges, and attrs of doc to g. Kinds are derived from node
+// names, so they are not read back.
+func (g *Graph) Load(doc Document) {
+	if g.edges == nil {
+		g.edges = make(map[string]map[string]struct{})
+	}
+	if g.reverse == nil {
+		g.reverse = make(map[string]map[string]struct{})
+	}
+	for _, n := range doc.Nodes {
+		g.Touch(n)
+	}
+	for _, e := range doc.Edges {
+		g.AddEdge(e.From, e.To)
+		for _, spec := range e.Specs {
+			g.AddEdgeSpec(e.From, e.To, spec)
+		}
+		if e.Kind != "" {
+			g.SetEdgeKind(e.From, e.To, e.Kind)
+		}
+	}
+	for n, a := range doc.Attrs {
+		g.SetAttrs(n, a)
+	}
+}
+
+// Whenever we do json.Marshall(g), this method will be called
+// it must return json or an error
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.Document())
+}
+
+// UnmarshalJSON loads a graph previously written by MarshalJSON, so saved
+// graph.json files can be queried without rebuilding.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	g.Load(doc)
+	return nil
 }
 
 func (g *Graph) Touch(n string) {
@@ -163,6 +536,15 @@ func (g *Graph) Touch(n string) {
 	}
 }
 
+// Has reports whether n is a node in the graph.
+func (g *Graph) Has(n string) bool {
+	if _, ok := g.edges[n]; ok {
+		return true
+	}
+	_, ok := g.reverse[n]
+	return ok
+}
+
 // ForEachEdge calls visit for every directed edge in the graph.
 // visit is invoked with (from, to) for each edge.
 func (g *Graph) ForEachEdge(visit func(from, to string)) {
@@ -209,3 +591,14 @@ func (g *Graph) InNeighbors(n string) []string {
 	sort.Strings(out)
 	return out
 }
+
+// ExternalUsage returns, for each external ("pkg:") node, its number of importers.
+func (g *Graph) ExternalUsage() map[string]int {
+	out := map[string]int{}
+	for _, n := range g.Nodes() {
+		if KindOf(n) == KindExternal {
+			out[n] = len(g.reverse[n])
+		}
+	}
+	return out
+}
@@ -0,0 +1,444 @@
+package graph
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCyclesAndSCC(t *testing.T) {
+	g := New()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "pkg:x")
+
+	if got, want := len(g.SCC()), 3; got != want {
+		t.Fatalf("expected %d components, got %d: %v", want, got, g.SCC())
+	}
+	want := [][]string{{"a", "b", "c"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Cycles() = %v, want %v", got, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	g := New()
+	g.AddEdge("a.ts", "b.ts")
+	g.AddEdge("b.ts", "c.ts")
+	g.AddEdge("c.ts", "a.ts")
+	g.AddEdge("c.ts", "d.ts")
+	g.AddEdge("d.ts", "pkg:x")
+
+	want := Metrics{
+		Nodes: 5, Edges: 5, Internal: 4,
+		Density:  4.0 / 12,
+		AvgFanIn: 1, AvgFanOut: 1.25,
+		MaxFanIn: 1, MaxFanOut: 2,
+		Cycles: 1, SCCs: 3,
+	}
+	if got := g.Metrics(); got != want {
+		t.Fatalf("Metrics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLayerMatrix(t *testing.T) {
+	g := New()
+	g.AddEdge("ui/page.ts", "ui/button.ts")
+	g.AddEdge("ui/page.ts", "domain/cart.ts")
+	g.AddEdge("ui/button.ts", "infra/http.ts")
+	g.AddEdge("domain/cart.ts", "infra/http.ts")
+	g.AddEdge("infra/http.ts", "pkg:axios")
+	g.AddEdge("scripts/seed.ts", "domain/cart.ts")
+
+	layerOf := func(n string) string {
+		if dir, _, ok := strings.Cut(n, "/"); ok && dir != "scripts" {
+			return dir
+		}
+		return ""
+	}
+	got := g.LayerMatrix([]string{"ui", "domain", "infra"}, layerOf)
+	want := LayerMatrix{
+		Layers: []string{"ui", "domain", "infra"},
+		Counts: [][]int{{1, 1, 1}, {0, 0, 1}, {0, 0, 0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LayerMatrix() = %+v, want %+v", got, want)
+	}
+}
+
+func TestImpactedDepth(t *testing.T) {
+	g := New()
+	g.AddEdge("page.ts", "button.ts")
+	g.AddEdge("button.ts", "util.ts")
+	g.AddEdge("form.ts", "util.ts")
+	g.AddEdge("app.ts", "page.ts")
+	g.AddEdge("app.ts", "form.ts")
+	g.AddEdge("util.ts", "app.ts") // cycle back through the start's importers
+
+	for depth, want := range map[int]map[string]int{
+		1: {"button.ts": 1, "form.ts": 1},
+		2: {"button.ts": 1, "form.ts": 1, "page.ts": 2, "app.ts": 2},
+		0: {"button.ts": 1, "form.ts": 1, "page.ts": 2, "app.ts": 2},
+	} {
+		if got := g.ImpactedDepth("util.ts", depth); !reflect.DeepEqual(got, want) {
+			t.Fatalf("ImpactedDepth(util.ts, %d) = %v, want %v", depth, got, want)
+		}
+	}
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("a.ts", "b.ts", "./b")
+	g.AddEdge("b.ts", "c.ts")
+	g.AddEdge("a.ts", "c.ts") // implied by a -> b -> c
+	g.AddEdge("c.ts", "d.ts")
+	g.AddEdge("d.ts", "c.ts") // c and d form a cycle
+	g.AddEdge("a.ts", "d.ts") // implied through the cycle
+	g.AddEdge("d.ts", "e.ts")
+	g.AddEdge("c.ts", "e.ts") // both kept: the edges leave the same component
+	g.AddEdge("x.ts", "e.ts")
+
+	r := g.TransitiveReduction()
+	var got []string
+	r.ForEachEdge(func(from, to string) { got = append(got, from+">"+to) })
+	sort.Strings(got)
+	want := []string{"a.ts>b.ts", "b.ts>c.ts", "c.ts>d.ts", "c.ts>e.ts", "d.ts>c.ts", "d.ts>e.ts", "x.ts>e.ts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("edges = %v, want %v", got, want)
+	}
+	if specs := r.Specs("a.ts", "b.ts"); !reflect.DeepEqual(specs, []string{"./b"}) {
+		t.Fatalf("specs not kept: %v", specs)
+	}
+	for _, n := range g.Nodes() {
+		if !reflect.DeepEqual(r.Reachable(n), g.Reachable(n)) {
+			t.Fatalf("reachability from %s changed: %v vs %v", n, r.Reachable(n), g.Reachable(n))
+		}
+	}
+}
+
+func TestEgoGraph(t *testing.T) {
+	g := New()
+	g.AddEdge("app.ts", "page.ts")
+	g.AddEdge("page.ts", "button.ts")
+	g.AddEdge("button.ts", "icon.ts")
+	g.AddEdge("other.ts", "button.ts")
+	g.AddEdge("other.ts", "far.ts")
+
+	for radius, want := range map[int][]string{
+		0: {"button.ts"},
+		1: {"button.ts", "icon.ts", "other.ts", "page.ts"},
+		2: {"app.ts", "button.ts", "far.ts", "icon.ts", "other.ts", "page.ts"},
+	} {
+		ego := g.EgoGraph("button.ts", radius)
+		if got := ego.Nodes(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("radius %d: nodes = %v, want %v", radius, got, want)
+		}
+	}
+	if got := g.EgoGraph("page.ts", 1).OutNeighbors("page.ts"); !reflect.DeepEqual(got, []string{"button.ts"}) {
+		t.Fatalf("expected edges among kept nodes, got %v", got)
+	}
+	if got := g.EgoGraph("missing.ts", 3).Nodes(); len(got) != 0 {
+		t.Fatalf("expected an empty graph for a missing node, got %v", got)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := New()
+	g.AddEdge("b.tsx", "pkg:react")
+	g.AddEdge("a.tsx", "b.tsx")
+	g.SetEdgeKind("a.tsx", "c.tsx", EdgeImported)
+
+	var b strings.Builder
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph philtographer {
+  rankdir=LR;
+  "a.tsx";
+  "b.tsx";
+  "c.tsx";
+  "pkg:react" [shape=box];
+  "a.tsx" -> "b.tsx";
+  "a.tsx" -> "c.tsx" [label="imported", style=dashed];
+  "b.tsx" -> "pkg:react";
+}
+`
+	if b.String() != want {
+		t.Fatalf("WriteDOT() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestEdgeSpecsRoundTrip(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("a.ts", "button.tsx", "./button")
+	g.AddEdgeSpec("a.ts", "button.tsx", "./button.tsx")
+	g.AddEdge("a.ts", "pkg:react")
+	g.SetEdgeKind("a.ts", "card.tsx", EdgeImported)
+
+	data, err := g.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Graph
+	if err := back.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := back.Specs("a.ts", "button.tsx"), []string{"./button", "./button.tsx"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Specs() = %v, want %v", got, want)
+	}
+	if got := back.Specs("a.ts", "pkg:react"); got != nil {
+		t.Fatalf("expected no specs for plain edge, got %v", got)
+	}
+	if got := back.EdgeKind("a.ts", "card.tsx"); got != EdgeImported {
+		t.Fatalf("EdgeKind() = %q, want %q", got, EdgeImported)
+	}
+}
+
+func TestExternalUsage(t *testing.T) {
+	g := New()
+	g.AddEdge("a.ts", "pkg:react")
+	g.AddEdge("b.ts", "pkg:react")
+	g.AddEdge("b.ts", "pkg:clsx")
+	g.AddEdge("a.ts", "b.ts")
+
+	want := map[string]int{"pkg:react": 2, "pkg:clsx": 1}
+	if got := g.ExternalUsage(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExternalUsage() = %v, want %v", got, want)
+	}
+}
+
+func TestDependenciesAndDegrees(t *testing.T) {
+	g := New()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("d", "b")
+
+	if got, want := g.Dependencies("a"), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Dependencies(a) = %v, want %v", got, want)
+	}
+	if in, out := g.Degrees("b"); in != 2 || out != 1 {
+		t.Fatalf("Degrees(b) = (%d, %d), want (2, 1)", in, out)
+	}
+}
+
+func TestInverted(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("app", "button", "./button")
+	g.SetEdgeKind("app", "button", EdgeCSSModule)
+	g.AddEdge("button", "pkg:react")
+	g.SetAttrs("app", NodeAttrs{Lines: 10})
+
+	inv := g.Inverted()
+	if got, want := inv.OutNeighbors("button"), []string{"app"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("OutNeighbors(button) = %v, want %v", got, want)
+	}
+	if got := inv.OutNeighbors("app"); len(got) != 0 {
+		t.Fatalf("OutNeighbors(app) = %v, want none", got)
+	}
+	if got, want := inv.Specs("button", "app"), []string{"./button"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Specs(button, app) = %v, want %v", got, want)
+	}
+	if k := inv.EdgeKind("button", "app"); k != EdgeCSSModule {
+		t.Fatalf("EdgeKind(button, app) = %q, want %q", k, EdgeCSSModule)
+	}
+	if a, _ := inv.Attrs("app"); a.Lines != 10 {
+		t.Fatalf("attrs not kept: %+v", a)
+	}
+	if got := g.OutNeighbors("app"); !reflect.DeepEqual(got, []string{"button"}) {
+		t.Fatalf("original graph changed: OutNeighbors(app) = %v", got)
+	}
+}
+
+func TestCommonDependencies(t *testing.T) {
+	g := New()
+	g.AddEdge("home", "layout")
+	g.AddEdge("home", "api")
+	g.AddEdge("settings", "layout")
+	g.AddEdge("settings", "form")
+	g.AddEdge("layout", "theme")
+	g.AddEdge("api", "theme")
+
+	if got, want := g.CommonDependencies("home", "settings"), []string{"layout", "theme"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("CommonDependencies(home, settings) = %v, want %v", got, want)
+	}
+	if got := g.CommonDependencies("form", "home"); len(got) != 0 {
+		t.Fatalf("CommonDependencies(form, home) = %v, want none", got)
+	}
+}
+
+func TestImpactedFromFile(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.ts"), filepath.Join(dir, "b.ts"), filepath.Join(dir, "c.ts")
+	g := New()
+	g.AddEdge(a, b)
+	g.AddEdge(b, c)
+	g.AddEdge("rel.ts", c)
+	data, err := g.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "graph.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// an unclean absolute path and a node recorded relatively
+	got, err := ImpactedFromFile(path, []string{filepath.Join(dir, "x", "..", "c.ts"), "./rel.ts"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{a, b, "rel.ts"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ImpactedFromFile() = %v, want %v", got, want)
+	}
+	if _, err := ImpactedFromFile(filepath.Join(dir, "missing.json"), nil); err == nil {
+		t.Fatal("expected an error for a missing graph file")
+	}
+}
+
+func TestSchemaMatchesDocument(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("a.tsx", "b.tsx", "./b")
+	g.SetEdgeKind("a.tsx", "b.tsx", EdgeRendered)
+	g.SetAttrs("a.tsx", NodeAttrs{Bytes: 10, Lines: 2, Boundary: true})
+	g.AddEdge("a.tsx", "pkg:react")
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every key written must be declared, and every required key written.
+	var check func(path string, v any, s map[string]any)
+	check = func(path string, v any, s map[string]any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if props, ok := s["properties"].(map[string]any); ok {
+				for k, sub := range v {
+					ps, ok := props[k].(map[string]any)
+					if !ok {
+						t.Fatalf("%s.%s is not in the schema", path, k)
+					}
+					check(path+"."+k, sub, ps)
+				}
+				for _, k := range s["required"].([]string) {
+					if _, ok := v[k]; !ok {
+						t.Fatalf("%s.%s is required but missing", path, k)
+					}
+				}
+				return
+			}
+			for k, sub := range v {
+				check(path+"."+k, sub, s["additionalProperties"].(map[string]any))
+			}
+		case []any:
+			for _, sub := range v {
+				check(path+"[]", sub, s["items"].(map[string]any))
+			}
+		}
+	}
+	check("$", doc, Schema())
+
+	edge := Schema()["properties"].(map[string]any)["edges"].(map[string]any)["items"].(map[string]any)
+	if got := edge["required"].([]string); len(got) != 2 || got[0] != "From" || got[1] != "To" {
+		t.Fatalf("edge required = %v, want [From To]", got)
+	}
+}
+
+func TestCondensation(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("a.ts", "b.ts", "./b")
+	g.AddEdgeSpec("b.ts", "a.ts", "./a")
+	g.AddEdgeSpec("c.ts", "a.ts", "./a")
+	g.AddEdgeSpec("c.ts", "b.ts", "./b")
+	g.AddEdgeSpec("b.ts", "d.ts", "./d")
+	g.SetEdgeKind("c.ts", "a.ts", EdgeRendered)
+	g.SetEdgeKind("c.ts", "b.ts", EdgeImported)
+	g.SetAttrs("a.ts", NodeAttrs{Lines: 10})
+	g.SetAttrs("b.ts", NodeAttrs{Lines: 5})
+	g.SetAttrs("d.ts", NodeAttrs{Lines: 1})
+
+	dag, mapping := g.Condensation()
+	if want := []string{"c.ts", "d.ts", "scc:a.ts"}; !reflect.DeepEqual(dag.Nodes(), want) {
+		t.Fatalf("nodes = %v, want %v", dag.Nodes(), want)
+	}
+	if want := map[string]string{"a.ts": "scc:a.ts", "b.ts": "scc:a.ts", "c.ts": "c.ts", "d.ts": "d.ts"}; !reflect.DeepEqual(mapping, want) {
+		t.Fatalf("mapping = %v, want %v", mapping, want)
+	}
+	if cycles := dag.Cycles(); len(cycles) != 0 {
+		t.Fatalf("condensation has cycles: %v", cycles)
+	}
+	if got := dag.Specs("c.ts", "scc:a.ts"); !reflect.DeepEqual(got, []string{"./a", "./b"}) {
+		t.Fatalf("specs = %v, want both specs merged", got)
+	}
+	if k := dag.EdgeKind("c.ts", "scc:a.ts"); k != "" {
+		t.Fatalf("kind = %q, want none for edges of mixed kinds", k)
+	}
+	if !dag.Has("scc:a.ts") || len(dag.OutNeighbors("scc:a.ts")) != 1 {
+		t.Fatalf("expected scc:a.ts -> d.ts, got %v", dag.Document().Edges)
+	}
+	if a, _ := dag.Attrs("scc:a.ts"); a.Lines != 15 {
+		t.Fatalf("scc lines = %d, want 15", a.Lines)
+	}
+	if a, _ := dag.Attrs("d.ts"); a.Lines != 1 {
+		t.Fatalf("d.ts lines = %d, want 1", a.Lines)
+	}
+}
+
+func TestDependenciesDepth(t *testing.T) {
+	g := New()
+	g.AddEdge("app.ts", "page.ts")
+	g.AddEdge("app.ts", "form.ts")
+	g.AddEdge("page.ts", "button.ts")
+	g.AddEdge("button.ts", "util.ts")
+	g.AddEdge("form.ts", "util.ts")
+	g.AddEdge("util.ts", "app.ts") // cycle back to the start
+
+	for depth, want := range map[int]map[string]int{
+		1: {"page.ts": 1, "form.ts": 1},
+		2: {"page.ts": 1, "form.ts": 1, "button.ts": 2, "util.ts": 2},
+		0: {"page.ts": 1, "form.ts": 1, "button.ts": 2, "util.ts": 2},
+	} {
+		if got := g.DependenciesDepth("app.ts", depth); !reflect.DeepEqual(got, want) {
+			t.Fatalf("DependenciesDepth(app.ts, %d) = %v, want %v", depth, got, want)
+		}
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	g := New()
+	g.AddEdgeSpec("/repo/src/app.ts", "/repo/src/button.tsx", "./button")
+	g.SetEdgeKind("/repo/src/app.ts", "/repo/src/button.tsx", EdgeCSSModule)
+	g.AddEdge("/repo/src/button.tsx", "pkg:react")
+	g.SetAttrs("/repo/src/app.ts", NodeAttrs{Lines: 10})
+
+	rel := g.Relabel(func(n string) string { return strings.TrimPrefix(n, "/repo/") })
+	if got, want := rel.Nodes(), []string{"pkg:react", "src/app.ts", "src/button.tsx"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Nodes() = %v, want %v", got, want)
+	}
+	if got, want := rel.InNeighbors("src/button.tsx"), []string{"src/app.ts"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("InNeighbors(src/button.tsx) = %v, want %v", got, want)
+	}
+	if got, want := rel.Specs("src/app.ts", "src/button.tsx"), []string{"./button"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Specs = %v, want %v", got, want)
+	}
+	if k := rel.EdgeKind("src/app.ts", "src/button.tsx"); k != EdgeCSSModule {
+		t.Fatalf("EdgeKind = %q, want %q", k, EdgeCSSModule)
+	}
+	if a, _ := rel.Attrs("src/app.ts"); a.Lines != 10 {
+		t.Fatalf("attrs not kept: %+v", a)
+	}
+	if !g.Has("/repo/src/app.ts") {
+		t.Fatal("original graph changed")
+	}
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Node kinds, as emitted under "kinds" in the serialized graph.
+const (
+	KindInternal = "internal" // source files in the workspace
+	KindExternal = "external" // bare package specs, recorded as "pkg:<name>"
+	KindStyle    = "style"    // stylesheets
+	KindAsset    = "asset"    // images, media, fonts
+	KindData     = "data"     // JSON/YAML/TOML and similar
+)
+
+// Edge kinds, as emitted under "kind" on edges.
+const (
+	EdgeRendered    = "rendered"    // the importer renders the component in JSX
+	EdgeImported    = "imported"    // the component is imported but never rendered
+	EdgeCSSModule   = "css-module"  // the importer uses the stylesheet's classes (x.module.css)
+	EdgeApproximate = "approximate" // a path built at runtime (require('./x/' + name)) may name the file
+)
+
+// KindOf classifies a node by its name: the "pkg:" prefix marks externals, and
+// file extensions distinguish styles, assets, and data from internal sources.
+func KindOf(n string) string {
+	if strings.HasPrefix(n, "pkg:") {
+		return KindExternal
+	}
+	switch strings.ToLower(filepath.Ext(n)) {
+	case ".css", ".scss", ".sass", ".less", ".styl":
+		return KindStyle
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico", ".bmp",
+		".mp3", ".mp4", ".webm", ".wav", ".ogg",
+		".woff", ".woff2", ".ttf", ".otf", ".eot":
+		return KindAsset
+	case ".json", ".json5", ".yml", ".yaml", ".toml", ".csv", ".txt":
+		return KindData
+	}
+	return KindInternal
+}
+
+// NodeKinds returns the kind of every node in the graph.
+func (g *Graph) NodeKinds() map[string]string {
+	nodes := g.Nodes()
+	out := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		out[n] = KindOf(n)
+	}
+	return out
+}
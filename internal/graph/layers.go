@@ -0,0 +1,45 @@
+package graph
+
+// LayerMatrix counts edges between named groups of nodes, a dependency-structure
+// matrix: Counts[i][j] is the number of edges from a node in Layers[i] to a node
+// in Layers[j].
+type LayerMatrix struct {
+	Layers []string `json:"layers"`
+	Counts [][]int  `json:"counts"`
+}
+
+// LayerMatrix aggregates the graph's edges by layer. layerOf names each node's
+// layer; edges touching a node whose layer isn't in layers (or is "") are left
+// out. Rows and columns follow the order of layers.
+func (g *Graph) LayerMatrix(layers []string, layerOf func(node string) string) LayerMatrix {
+	index := make(map[string]int, len(layers))
+	for i, l := range layers {
+		index[l] = i
+	}
+	m := LayerMatrix{Layers: layers, Counts: make([][]int, len(layers))}
+	for i := range m.Counts {
+		m.Counts[i] = make([]int, len(layers))
+	}
+	of := map[string]int{}
+	lookup := func(n string) (int, bool) {
+		if i, ok := of[n]; ok {
+			return i, i >= 0
+		}
+		i, ok := index[layerOf(n)]
+		if !ok {
+			i = -1
+		}
+		of[n] = i
+		return i, ok
+	}
+	g.ForEachEdge(func(from, to string) {
+		i, ok := lookup(from)
+		if !ok {
+			return
+		}
+		if j, ok := lookup(to); ok {
+			m.Counts[i][j]++
+		}
+	})
+	return m
+}
@@ -0,0 +1,57 @@
+package graph
+
+// Metrics summarizes the shape of a graph, for trending architectural health
+// across commits.
+type Metrics struct {
+	Nodes     int     `json:"nodes"`
+	Edges     int     `json:"edges"`
+	Internal  int     `json:"internal"`  // nodes of KindInternal
+	Density   float64 `json:"density"`   // edges among internal nodes / n*(n-1)
+	AvgFanIn  float64 `json:"avgFanIn"`  // mean importers per internal node
+	AvgFanOut float64 `json:"avgFanOut"` // mean imports per internal node, externals included
+	MaxFanIn  int     `json:"maxFanIn"`
+	MaxFanOut int     `json:"maxFanOut"`
+	Cycles    int     `json:"cycles"` // strongly connected components with more than one node
+	SCCs      int     `json:"sccs"`   // all strongly connected components, singletons included
+}
+
+// Metrics computes node and edge counts, density, fan-in/fan-out, and cycle and
+// SCC counts. Density and fan-in/fan-out consider internal nodes only, so a graph
+// full of pkg: leaves doesn't look sparse.
+func (g *Graph) Metrics() Metrics {
+	var m Metrics
+	internal := map[string]bool{}
+	var sumIn, sumOut int
+	for _, n := range g.Nodes() {
+		m.Nodes++
+		if KindOf(n) != KindInternal {
+			continue
+		}
+		internal[n] = true
+		in, out := g.Degrees(n)
+		sumIn += in
+		sumOut += out
+		m.MaxFanIn = max(m.MaxFanIn, in)
+		m.MaxFanOut = max(m.MaxFanOut, out)
+	}
+	m.Internal = len(internal)
+
+	var internalEdges int
+	g.ForEachEdge(func(from, to string) {
+		m.Edges++
+		if internal[from] && internal[to] && from != to {
+			internalEdges++
+		}
+	})
+	if n := m.Internal; n > 0 {
+		m.AvgFanIn = float64(sumIn) / float64(n)
+		m.AvgFanOut = float64(sumOut) / float64(n)
+		if n > 1 {
+			m.Density = float64(internalEdges) / float64(n*(n-1))
+		}
+	}
+
+	m.Cycles = len(g.Cycles())
+	m.SCCs = len(g.SCC())
+	return m
+}
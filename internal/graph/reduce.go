@@ -0,0 +1,60 @@
+package graph
+
+// TransitiveReduction returns a copy of the graph without edges implied by longer
+// paths: A -> C is dropped when A -> B -> ... -> C exists. Reachability between
+// every pair of nodes is unchanged.
+//
+// On a DAG the result is the unique minimal graph. With cycles, the reduction is
+// done on the condensation (each strongly connected component as one node): edges
+// inside a component are all kept, and an edge between two components is dropped
+// when the target component is reachable through a third one.
+func (g *Graph) TransitiveReduction() *Graph {
+	sccs, comp := g.sccIndex()
+	// succ[i] holds the components directly imported from component i
+	succ := make([]map[int]bool, len(sccs))
+	for i := range succ {
+		succ[i] = map[int]bool{}
+	}
+	g.ForEachEdge(func(from, to string) {
+		if a, b := comp[from], comp[to]; a != b {
+			succ[a][b] = true
+		}
+	})
+	// implied[i] holds the direct successors of i also reachable through another one
+	implied := make([]map[int]bool, len(sccs))
+	for i := range sccs {
+		seen := map[int]bool{}
+		var visit func(c int)
+		visit = func(c int) {
+			for next := range succ[c] {
+				if !seen[next] {
+					seen[next] = true
+					visit(next)
+				}
+			}
+		}
+		for d := range succ[i] {
+			visit(d)
+		}
+		implied[i] = map[int]bool{}
+		for d := range succ[i] {
+			if seen[d] {
+				implied[i][d] = true
+			}
+		}
+	}
+
+	out := New()
+	for _, n := range g.Nodes() {
+		out.Touch(n)
+		if a, ok := g.attrs[n]; ok {
+			out.attrs[n] = a
+		}
+	}
+	g.ForEachEdge(func(from, to string) {
+		if !implied[comp[from]][comp[to]] {
+			g.copyEdge(out, from, to)
+		}
+	})
+	return out
+}
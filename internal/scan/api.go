@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"os"
+	"sort"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// Export is one name in a module's public API and the file that declares it.
+type Export struct {
+	Name string `json:"name"` // as importers see it; "default" for the default export
+	// File declares the export. A name re-exported from a package is attributed
+	// to its "pkg:" node, and one whose declaration can't be found to the module
+	// it is re-exported from.
+	File string `json:"file"`
+}
+
+// PublicAPI returns the names entry exports, sorted: its own declarations plus
+// everything it re-exports, followed transitively through barrels. Re-exports
+// are matched to files through the edges and specs of g, and sources are read
+// from disk at their node paths. As in ES modules, "export * from" passes on
+// every name but "default", names a file exports explicitly win over ones it
+// gets through *, and a name two * re-exports bring from different files is
+// ambiguous and left out.
+func PublicAPI(g *graph.Graph, entry string) []Export {
+	w := &apiWalk{g: g, done: map[string][]Export{}, active: map[string]bool{}}
+	return w.surface(entry)
+}
+
+// apiWalk caches each file's surface for PublicAPI.
+type apiWalk struct {
+	g      *graph.Graph
+	done   map[string][]Export
+	active map[string]bool // files being expanded; a cycle back to one adds nothing
+}
+
+func (w *apiWalk) surface(file string) []Export {
+	if out, ok := w.done[file]; ok {
+		return out
+	}
+	if w.active[file] {
+		return nil
+	}
+	w.active[file] = true
+	defer delete(w.active, file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		w.done[file] = nil
+		return nil
+	}
+	content := reComments.ReplaceAllString(string(NormalizeSource(data)), "")
+	byName := map[string]Export{}  // declared here or re-exported by name
+	star := map[string]Export{}    // reached through export * only
+	ambiguous := map[string]bool{} // reached through two export * of different files
+	add := func(e Export) { byName[e.Name] = e }
+	addStar := func(e Export) {
+		if prev, ok := star[e.Name]; ok && prev.File != e.File {
+			ambiguous[e.Name] = true
+		}
+		star[e.Name] = e
+	}
+	local := reReexport.ReplaceAllString(content, "")
+	for _, m := range reExportDecl.FindAllStringSubmatch(local, -1) {
+		add(Export{Name: m[1], File: file})
+	}
+	for _, m := range reExportList.FindAllStringSubmatch(local, -1) {
+		for _, n := range splitNames(m[1]) {
+			add(Export{Name: n.as, File: file})
+		}
+	}
+	if reExportDflt.MatchString(local) {
+		add(Export{Name: "default", File: file})
+	}
+	for spec, names := range reexportsOf(content) {
+		target := w.target(file, spec)
+		var inner []Export
+		if target != "" && graph.KindOf(target) != graph.KindExternal {
+			inner = w.surface(target)
+		}
+		if target == "" {
+			target = spec
+		}
+		for _, n := range names {
+			switch {
+			case n.from == allNames && n.as == allNames:
+				for _, e := range inner {
+					if e.Name != "default" {
+						addStar(e)
+					}
+				}
+			case n.from == allNames:
+				add(Export{Name: n.as, File: target})
+			default:
+				e := Export{Name: n.as, File: target}
+				for _, in := range inner {
+					if in.Name == n.from {
+						e.File = in.File
+						break
+					}
+				}
+				add(e)
+			}
+		}
+	}
+	for name, e := range star {
+		if _, ok := byName[name]; !ok && !ambiguous[name] {
+			byName[name] = e
+		}
+	}
+
+	out := make([]Export, 0, len(byName))
+	for _, e := range byName {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	w.done[file] = out
+	return out
+}
+
+// target returns the node file's import of spec resolved to, or "".
+func (w *apiWalk) target(file, spec string) string {
+	for _, to := range w.g.OutNeighbors(file) {
+		for _, s := range w.g.Specs(file, to) {
+			if s == spec {
+				return to
+			}
+		}
+	}
+	return ""
+}
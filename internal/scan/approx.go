@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// IsApproximate reports whether spec is a glob standing for a require or import
+// whose path is built at runtime ("./handlers/*" for require('./handlers/' + name)).
+// Edges from such a spec go to every file it could name, labeled
+// graph.EdgeApproximate.
+func IsApproximate(spec string) bool {
+	return isRelativeImport(spec) && strings.HasSuffix(spec, "*")
+}
+
+// approximateSpec turns the static prefix of a concatenated path into a glob spec,
+// or returns "" when the prefix isn't relative (a package's internals can't be
+// listed).
+func approximateSpec(prefix string) string {
+	if !isRelativeImport(prefix) || strings.Contains(prefix, "*") {
+		return ""
+	}
+	return prefix + "*"
+}
+
+// ExpandApproximate returns the source files in the directory of an approximate
+// spec (see IsApproximate) whose names start with its static prefix, resolved
+// against fromFile's directory. fromFile itself is left out. Sorted.
+func ExpandApproximate(fromFile, spec string) []string {
+	prefix := strings.TrimSuffix(spec, "*")
+	full := filepath.Join(filepath.Dir(fromFile), prefix)
+	dir, base := full, ""
+	if !strings.HasSuffix(prefix, "/") {
+		dir, base = filepath.Dir(full), filepath.Base(full)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || !strings.HasPrefix(e.Name(), base) || !isSource(path) || path == fromFile {
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+// addApproximateEdge records from -> to for an approximate spec, labeled
+// graph.EdgeApproximate unless an exact import already produced the edge.
+func addApproximateEdge(g *graph.Graph, from, to, spec string) {
+	exact := g.Specs(from, to) != nil
+	g.AddEdgeSpec(from, to, spec)
+	if !exact {
+		g.SetEdgeKind(from, to, graph.EdgeApproximate)
+	}
+}
+
+// ComputedImport is an import() or require() call whose argument is neither a
+// string nor a template literal (import(name), require(base + '/x')). Nothing
+// about its target is known, so the graph is missing whatever it loads.
+type ComputedImport struct {
+	File string
+	Line int    // 1-based; 0 when the file went through a preprocessor
+	Expr string // the argument as written
+}
+
+// reComputed matches import( and require( calls, not methods (x.require(...)),
+// whose argument starts with something other than a quote; one level of nested
+// parentheses is allowed in it (import(pathFor(name))).
+var reComputed = regexp.MustCompile(`(?:^|[^.\w$])(?:import|require)\(\s*([^\s'"` + "`" + `()](?:[^()\n]|\([^()\n]*\))*)\)`)
+
+// computedImports returns the import()/require() calls in content that can't be
+// resolved statically, with File unset. Leading block comments in the argument
+// (webpack's /* webpackChunkName: "x" */) are skipped before deciding, and
+// function declarations named require are not calls.
+func computedImports(content string) []ComputedImport {
+	content = string(NormalizeSource([]byte(content)))
+	var out []ComputedImport
+	for _, loc := range reComputed.FindAllStringSubmatchIndex(content, -1) {
+		expr := content[loc[2]:loc[3]]
+		arg := expr
+		for strings.HasPrefix(arg, "/*") {
+			_, rest, ok := strings.Cut(arg, "*/")
+			if !ok {
+				break
+			}
+			arg = strings.TrimSpace(rest)
+		}
+		if arg == "" || strings.ContainsRune("'\"`", rune(arg[0])) {
+			continue
+		}
+		if strings.HasSuffix(strings.TrimSpace(content[:loc[0]+1]), "function") {
+			continue
+		}
+		line := strings.Count(content[:loc[2]], "\n") + 1
+		out = append(out, ComputedImport{Line: line, Expr: strings.TrimSpace(expr)})
+	}
+	return out
+}
+
+// findComputedImports is computedImports for path, given its source after
+// preprocessing; line numbers are dropped when a preprocessor rewrote it.
+func findComputedImports(path string, src []byte) []ComputedImport {
+	found := computedImports(string(src))
+	_, rewritten := preprocessorFor(path)
+	for i := range found {
+		found[i].File = path
+		if rewritten {
+			found[i].Line = 0
+		}
+	}
+	return found
+}
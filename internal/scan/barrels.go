@@ -0,0 +1,318 @@
+package scan
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// Named-import tracking is regex based like ParseImports: good enough to tell which
+// symbols a file takes from each specifier, not a full parser.
+var (
+	reComments   = regexp.MustCompile(`(?s:/\*.*?\*/)|//[^\n]*`)
+	reImportStmt = regexp.MustCompile(`(?s)\bimport\s+(?:type\s+)?([^'";]*?)\s*from\s*['"]([^'"]+)['"]`)
+	reReexport   = regexp.MustCompile(`(?s)\bexport\s+(?:type\s+)?(\*\s+as\s+[\w$]+|\*|\{[^}]*\})\s*from\s*['"]([^'"]+)['"]`)
+	reStatement  = regexp.MustCompile(`(?s)[^;]+`)
+	reExportDecl = regexp.MustCompile(`\bexport\s+(?:declare\s+)?(?:async\s+)?(?:function\*?|const|let|var|class|interface|type|enum|abstract\s+class)\s+([\w$]+)`)
+	reExportList = regexp.MustCompile(`\bexport\s+(?:type\s+)?(\{[^}]*\})`)
+	reExportDflt = regexp.MustCompile(`\bexport\s+default\b`)
+)
+
+// allNames stands for "every export of the module": namespace imports, side effects,
+// require(), and anything the regexes can't break down.
+const allNames = "*"
+
+// ParseNamedImports returns, per specifier, the names content imports from it:
+// "default" for default imports, the exported name for named imports (before any
+// "as"), and "*" when the whole module is used (namespace, side-effect, require,
+// dynamic import, or export * from).
+func ParseNamedImports(content string) map[string][]string {
+	content = reComments.ReplaceAllString(content, "")
+	out := map[string][]string{}
+	add := func(spec string, names ...string) {
+		out[spec] = append(out[spec], names...)
+	}
+	for _, m := range reImportStmt.FindAllStringSubmatch(content, -1) {
+		clause, spec := strings.TrimSpace(m[1]), m[2]
+		if i := strings.Index(clause, "{"); i >= 0 {
+			if head := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(clause[:i]), ",")); head != "" {
+				add(spec, "default")
+			}
+			for _, n := range splitNames(clause[i:]) {
+				add(spec, n.from)
+			}
+			continue
+		}
+		if strings.HasPrefix(clause, "*") {
+			add(spec, allNames)
+			continue
+		}
+		add(spec, "default")
+		if strings.Contains(clause, ",") && strings.Contains(clause, "*") {
+			add(spec, allNames)
+		}
+	}
+	for _, m := range reImportBare.FindAllStringSubmatch(content, -1) {
+		add(m[1], allNames)
+	}
+	for _, re := range []*regexp.Regexp{reRequire, reDynamic} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			add(m[1], allNames)
+		}
+	}
+	for spec, names := range reexportsOf(content) {
+		for _, n := range names {
+			add(spec, n.from)
+		}
+	}
+	return out
+}
+
+// exportsOf returns the names content exports, or nil when they can't be listed
+// (an "export * from" pulls in names this file doesn't spell out).
+func exportsOf(content string) []string {
+	content = reComments.ReplaceAllString(content, "")
+	for _, r := range reexportsOf(content) {
+		for _, n := range r {
+			if n.from == allNames && n.as == allNames {
+				return nil
+			}
+		}
+	}
+	var out []string
+	for _, m := range reExportDecl.FindAllStringSubmatch(content, -1) {
+		out = append(out, m[1])
+	}
+	for _, m := range reExportList.FindAllStringSubmatch(content, -1) {
+		for _, n := range splitNames(m[1]) {
+			out = append(out, n.as)
+		}
+	}
+	if reExportDflt.MatchString(content) {
+		out = append(out, "default")
+	}
+	return out
+}
+
+// reexport maps an exported name back to the name it has in the re-exported module.
+type reexport struct{ from, as string }
+
+// reexportsOf returns content's "export ... from" statements per specifier.
+// "export * from" yields {*, *}; "export * as ns from" yields {*, ns}.
+func reexportsOf(content string) map[string][]reexport {
+	out := map[string][]reexport{}
+	for _, m := range reReexport.FindAllStringSubmatch(content, -1) {
+		clause, spec := strings.TrimSpace(m[1]), m[2]
+		switch {
+		case clause == "*":
+			out[spec] = append(out[spec], reexport{from: allNames, as: allNames})
+		case strings.HasPrefix(clause, "*"):
+			ns := strings.TrimSpace(clause[strings.LastIndex(clause, " ")+1:])
+			out[spec] = append(out[spec], reexport{from: allNames, as: ns})
+		default:
+			out[spec] = append(out[spec], splitNames(clause)...)
+		}
+	}
+	return out
+}
+
+// splitNames parses "{ a, b as c, type d }" into (imported, local) pairs.
+func splitNames(braces string) []reexport {
+	braces = strings.Trim(strings.TrimSpace(braces), "{}")
+	var out []reexport
+	for _, part := range strings.Split(braces, ",") {
+		f := strings.Fields(part)
+		if len(f) > 0 && f[0] == "type" && len(f) > 1 {
+			f = f[1:]
+		}
+		switch {
+		case len(f) == 1:
+			out = append(out, reexport{from: f[0], as: f[0]})
+		case len(f) == 3 && f[1] == "as":
+			out = append(out, reexport{from: f[0], as: f[2]})
+		}
+	}
+	return out
+}
+
+// barrelReexports reports whether content is a pure barrel (only "export ... from"
+// statements once comments are removed) and, if so, its re-exports per specifier.
+func barrelReexports(content string) (map[string][]reexport, bool) {
+	content = reComments.ReplaceAllString(content, "")
+	rest := reReexport.ReplaceAllString(content, "")
+	for _, stmt := range reStatement.FindAllString(rest, -1) {
+		if strings.TrimSpace(stmt) != "" {
+			return nil, false
+		}
+	}
+	re := reexportsOf(content)
+	return re, len(re) > 0
+}
+
+// ImpactedThroughBarrels is Graph.Impacted with pure re-export barrels treated as
+// transparent: a barrel passes impact on only for the symbols it re-exports from
+// the changed module, and its importers are impacted only if they import one of
+// those symbols. Barrels themselves are not reported. Source files are read from
+// disk at their node paths; edges without recorded specs, and files that can't be
+// read, fall back to plain propagation.
+func ImpactedThroughBarrels(g *graph.Graph, start string) []string {
+	t := &barrelWalk{
+		barrels:  map[string]map[string][]reexport{},
+		imports:  map[string]map[string][]string{},
+		exported: map[string][]string{},
+	}
+	result := map[string]bool{}
+	type item struct {
+		node  string
+		names []string // symbols of node that changed; nil = all of them
+	}
+	visited := map[string]bool{}
+	key := func(it item) string { return it.node + "\x00" + strings.Join(it.names, ",") }
+	queue := []item{{node: start}}
+	visited[key(queue[0])] = true
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		for _, p := range g.InNeighbors(it.node) {
+			next := item{node: p}
+			if re, ok := t.barrel(p); ok {
+				changed := it.names
+				if changed == nil {
+					changed = t.exports(it.node)
+				}
+				names, ok := passThrough(re, g.Specs(p, it.node), changed)
+				if !ok {
+					continue
+				}
+				next.names = names
+			} else {
+				if !usesAny(t.importedNames(p, g.Specs(p, it.node)), it.names) {
+					continue
+				}
+				result[p] = true
+			}
+			if k := key(next); !visited[k] {
+				visited[k] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	delete(result, start)
+	out := make([]string, 0, len(result))
+	for n := range result {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// barrelWalk caches per-file parsing for ImpactedThroughBarrels.
+type barrelWalk struct {
+	barrels  map[string]map[string][]reexport // nil value = not a barrel
+	imports  map[string]map[string][]string
+	exported map[string][]string
+}
+
+// exports returns the names file exports, or nil (all) when unknown.
+func (t *barrelWalk) exports(file string) []string {
+	names, ok := t.exported[file]
+	if !ok {
+		if data, err := os.ReadFile(file); err == nil {
+			names = exportsOf(string(NormalizeSource(data)))
+		}
+		t.exported[file] = names
+	}
+	return names
+}
+
+func (t *barrelWalk) barrel(file string) (map[string][]reexport, bool) {
+	re, ok := t.barrels[file]
+	if !ok {
+		if data, err := os.ReadFile(file); err == nil {
+			re, _ = barrelReexports(string(NormalizeSource(data)))
+		}
+		t.barrels[file] = re
+	}
+	return re, re != nil
+}
+
+// importedNames returns the names file imports through any of specs, or nil
+// (meaning unknown, treated as everything) when that can't be determined.
+func (t *barrelWalk) importedNames(file string, specs []string) []string {
+	if len(specs) == 0 {
+		return nil
+	}
+	named, ok := t.imports[file]
+	if !ok {
+		if data, err := os.ReadFile(file); err == nil {
+			named = ParseNamedImports(string(NormalizeSource(data)))
+		}
+		t.imports[file] = named
+	}
+	if named == nil {
+		return nil
+	}
+	var out []string
+	for _, s := range specs {
+		out = append(out, named[s]...)
+	}
+	if len(out) == 0 {
+		// the regexes didn't recognize the import; assume it uses everything
+		return nil
+	}
+	return out
+}
+
+// passThrough maps changed names of a module to the names a barrel exposes for them
+// via the re-exports under specs. It returns nil names for "all" and false when
+// nothing the barrel re-exports changed.
+func passThrough(re map[string][]reexport, specs []string, changed []string) ([]string, bool) {
+	if len(specs) == 0 {
+		return nil, true
+	}
+	set := map[string]bool{}
+	for _, s := range specs {
+		for _, r := range re[s] {
+			switch {
+			case r.from == allNames && r.as == allNames:
+				// export * from: the same names flow through
+				if changed == nil {
+					return nil, true
+				}
+				for _, c := range changed {
+					if c != "default" {
+						set[c] = true
+					}
+				}
+			case r.from == allNames || changed == nil || slices.Contains(changed, r.from):
+				set[r.as] = true
+			}
+		}
+	}
+	if len(set) == 0 {
+		return nil, false
+	}
+	out := make([]string, 0, len(set))
+	for n := range set {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out, true
+}
+
+// usesAny reports whether imported (nil = unknown) overlaps changed (nil = all).
+func usesAny(imported, changed []string) bool {
+	if imported == nil || changed == nil || slices.Contains(imported, allNames) {
+		return true
+	}
+	for _, c := range changed {
+		if slices.Contains(imported, c) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,17 +6,117 @@ type Config struct {
 	Root    string      `mapstructure:"root" json:"root" yaml:"root"`
 	Out     string      `mapstructure:"out" json:"out" yaml:"out"`
 	Entries []EntrySpec `mapstructure:"entries" json:"entries" yaml:"entries"`
+
+	// Skip lists file globs that are never parsed (matched against the base name, or the
+	// root-relative path when the pattern contains "/"). nil means DefaultSkip; set it to
+	// an empty list to scan everything.
+	Skip []string `mapstructure:"skip" json:"skip" yaml:"skip"`
+	// MaxLineLength treats files with any line longer than this as minified and skips
+	// them. 0 disables the heuristic.
+	MaxLineLength int `mapstructure:"maxLineLength" json:"maxLineLength" yaml:"maxLineLength"`
+	// MaxFiles aborts a full scan with ErrTooManyFiles once the walk finds more source
+	// files than this, so a root pointing at "/" fails fast. 0 means DefaultMaxFiles;
+	// a negative value disables the limit.
+	MaxFiles int `mapstructure:"maxFiles" json:"maxFiles" yaml:"maxFiles"`
+	// Only limits the full walk to this directory (relative to Root, or absolute).
+	// Imports are still resolved against Root; files outside Only that are imported
+	// from inside it become nodes marked Boundary, and their imports are not read.
+	Only string `mapstructure:"only" json:"only" yaml:"only"`
+	// FollowSymlinks makes the full walk descend into symlinked directories. Each real
+	// directory is walked once, so links pointing back up the tree don't loop.
+	FollowSymlinks bool `mapstructure:"followSymlinks" json:"followSymlinks" yaml:"followSymlinks"`
+	// ConfineRoot records imports that resolve outside Root (after following symlinks)
+	// in Manifest.OutOfRoot. The edges are still added.
+	ConfineRoot bool `mapstructure:"confineRoot" json:"confineRoot" yaml:"confineRoot"`
+	// ExcludeExternals drops imports that resolve to packages ("pkg:" nodes) as they
+	// are found, so the graph holds only workspace files.
+	ExcludeExternals bool `mapstructure:"excludeExternals" json:"excludeExternals" yaml:"excludeExternals"`
+	// ExternalsAllowlist keeps packages matching any of these globs (e.g. "react",
+	// "@acme/*") when ExcludeExternals is set. Patterns match the package name, so
+	// "react" also keeps "react/jsx-runtime".
+	ExternalsAllowlist []string `mapstructure:"externalsAllowlist" json:"externalsAllowlist" yaml:"externalsAllowlist"`
+	// DuplicateDeps locates the installed copy (the nearest node_modules/<name>)
+	// behind every bare import and reports packages found in more than one place in
+	// Manifest.DuplicateDeps. The graph still records them as "pkg:" nodes.
+	DuplicateDeps bool `mapstructure:"duplicateDeps" json:"duplicateDeps" yaml:"duplicateDeps"`
+	// NodeModulesDepth reads the installed packages that workspace files import and
+	// records their own package imports as "pkg:a" -> "pkg:b" edges, following the
+	// packages found that way this many levels deep. 0 leaves packages as leaves.
+	NodeModulesDepth int `mapstructure:"nodeModulesDepth" json:"nodeModulesDepth" yaml:"nodeModulesDepth"`
+	// CSSModules keeps imports of CSS modules (x.module.css, x.module.scss) as edges
+	// to the stylesheet, labeled graph.EdgeCSSModule. Other stylesheet imports are
+	// always dropped.
+	CSSModules bool `mapstructure:"cssModules" json:"cssModules" yaml:"cssModules"`
+	// StrictEntries makes a discovered entry whose file is missing (or isn't a
+	// source file) an error. By default such entries are logged and skipped.
+	StrictEntries bool `mapstructure:"strictEntries" json:"strictEntries" yaml:"strictEntries"`
+	// Extensions is the order extensionless specs and directory index files are probed
+	// in (e.g. [".tsx", ".ts"] to prefer index.tsx). nil means DefaultExtensions.
+	Extensions []string `mapstructure:"extensions" json:"extensions" yaml:"extensions"`
+	// Workspaces lists workspace package directory globs relative to Root (e.g.
+	// "packages/*"). Imports of those packages by name, including subpaths, resolve
+	// to their source files.
+	Workspaces []string `mapstructure:"workspaces" json:"workspaces" yaml:"workspaces"`
+	// AutoWorkspaces, when Workspaces is empty, takes the workspace globs from the
+	// root package.json "workspaces" field or pnpm-workspace.yaml (see
+	// DetectWorkspaces). The CLI defaults it to true.
+	AutoWorkspaces bool `mapstructure:"autoWorkspaces" json:"autoWorkspaces" yaml:"autoWorkspaces"`
+	// ImportMap is a browser import map file (relative to Root, or absolute). Bare specs
+	// it maps to local paths resolve to those files; ones mapped to URLs stay "pkg:" nodes.
+	ImportMap string `mapstructure:"importMap" json:"importMap" yaml:"importMap"`
+	// BaseDirs are more directories (relative to Root) bare specs are looked up in,
+	// in order, after tsconfig's baseUrl, like webpack's resolve.roots.
+	BaseDirs []string `mapstructure:"baseDirs" json:"baseDirs" yaml:"baseDirs"`
+	// PnP resolves bare specs through the Yarn Plug'n'Play data at Root
+	// (.pnp.data.json or .pnp.cjs) to files inside the installed packages, instead
+	// of leaving them as "pkg:" nodes.
+	PnP bool `mapstructure:"pnp" json:"pnp" yaml:"pnp"`
+	// RelativeIDs makes the CLI write file nodes as forward-slashed paths relative
+	// to the git checkout containing Root ("vcs") or to Root ("root") instead of
+	// as scanned, so graphs from different machines compare equal. "" keeps them.
+	RelativeIDs string `mapstructure:"relativeIds" json:"relativeIds" yaml:"relativeIds"`
+
+	// Layers assigns files to named architectural layers (ui, domain, infra, ...) for
+	// the layers report. Rules are tried in order and the first matching one wins,
+	// which is why this is a list rather than a glob -> layer map.
+	Layers []LayerRule `mapstructure:"layers" json:"layers" yaml:"layers"`
+
+	// OnEdge, when set, receives each resolved edge (with the specifier that produced it
+	// and its graph edge kind, "" for plain imports) as it is discovered, and the edge is
+	// not stored in the returned graph. Nodes and attrs are still recorded. Calls are
+	// serialized.
+	OnEdge func(from, to, spec, kind string) `mapstructure:"-" json:"-" yaml:"-"`
+
+	// Progress, when set, is called by BuildGraphWithConfig after each file it
+	// receives from the workers. Calls are serialized.
+	Progress func(Progress) `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+// Progress is a snapshot of a full scan. The walk that finds files runs ahead of
+// parsing, so Found is usually final (Counted) long before Done catches up.
+type Progress struct {
+	Done    int  // files processed (parsed, skipped, or failed)
+	Found   int  // source files found by the walk so far
+	Counted bool // the walk has finished, so Found is the total
 }
 
 // EntrySpec is a discriminated union. The CLI layer will map these into real providers.
 type EntrySpec struct {
 	Type string `mapstructure:"type" json:"type" yaml:"type"`
 
-	// rootsTs fields
+	// rootsTs fields (file is shared with the file type: the entries list to read)
 	File     string `mapstructure:"file" json:"file" yaml:"file"`
 	NameFrom string `mapstructure:"nameFrom" json:"nameFrom" yaml:"nameFrom"`
 
+	// rootsTs member shape: a preset Style ("moduleFactory" or "call"), or a Regex
+	// capturing key, chunkName, and import path in that order
+	Style string `mapstructure:"style" json:"style" yaml:"style"`
+	Regex string `mapstructure:"regex" json:"regex" yaml:"regex"`
+
 	// explicit fields
 	Name string `mapstructure:"name" json:"name" yaml:"name"`
 	Path string `mapstructure:"path" json:"path" yaml:"path"`
+
+	// glob fields (nameFrom is shared with rootsTs)
+	Pattern string `mapstructure:"pattern" json:"pattern" yaml:"pattern"`
 }
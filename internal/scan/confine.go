@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// rootChecker reports whether resolved paths fall outside a root, comparing both
+// the lexical path and the symlink-resolved one.
+type rootChecker struct {
+	root, realRoot string
+}
+
+func newRootChecker(root string) rootChecker {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	realRoot, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		realRoot = abs
+	}
+	return rootChecker{root: abs, realRoot: realRoot}
+}
+
+// outside reports whether file (a resolved import target) lies outside the root.
+func (c rootChecker) outside(file string) bool {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	if !within(c.root, abs) {
+		return true
+	}
+	if realFile, err := filepath.EvalSymlinks(abs); err == nil && !within(c.realRoot, realFile) {
+		return true
+	}
+	return false
+}
+
+func within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
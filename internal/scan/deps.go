@@ -0,0 +1,117 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DuplicateDep is a package that imports resolve to from more than one
+// node_modules location, so a bundle may carry several copies of it.
+type DuplicateDep struct {
+	Name   string
+	Copies []PackageCopy // sorted by Dir
+}
+
+// PackageCopy is one installed copy of a package.
+type PackageCopy struct {
+	Dir       string // the package directory, e.g. /repo/packages/a/node_modules/react
+	Version   string // from its package.json, if set
+	Importers int    // files whose imports resolved to this copy
+}
+
+// depTracker records which installed copy each bare import resolves to, node
+// style: the nearest node_modules/<name> above the importing file. It is safe for
+// concurrent use.
+type depTracker struct {
+	mu     sync.Mutex
+	lookup map[[2]string]string                  // (dir, name) -> package dir, "" when not installed
+	copies map[string]map[string]map[string]bool // name -> package dir -> importers
+}
+
+func newDepTracker() *depTracker {
+	return &depTracker{lookup: map[[2]string]string{}, copies: map[string]map[string]map[string]bool{}}
+}
+
+// record notes that from imports the package behind a "pkg:" target. Other
+// targets, and packages not installed anywhere above from, are ignored.
+func (t *depTracker) record(from, to string) {
+	if t == nil {
+		return
+	}
+	spec, ok := strings.CutPrefix(to, "pkg:")
+	if !ok {
+		return
+	}
+	name := PackageName(spec)
+	dir := t.locate(filepath.Dir(from), name)
+	if dir == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.copies[name] == nil {
+		t.copies[name] = map[string]map[string]bool{}
+	}
+	if t.copies[name][dir] == nil {
+		t.copies[name][dir] = map[string]bool{}
+	}
+	t.copies[name][dir][from] = true
+}
+
+// locate returns the nearest node_modules/<name> directory at or above dir that
+// holds a package.json, caching the answer for every directory it walked through.
+func (t *depTracker) locate(dir, name string) string {
+	var walked []string
+	found := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		t.mu.Lock()
+		cached, ok := t.lookup[[2]string{d, name}]
+		t.mu.Unlock()
+		if ok {
+			found = cached
+			break
+		}
+		walked = append(walked, d)
+		cand := filepath.Join(d, "node_modules", filepath.FromSlash(name))
+		if _, err := os.Stat(filepath.Join(cand, "package.json")); err == nil {
+			found = cand
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	t.mu.Lock()
+	for _, d := range walked {
+		t.lookup[[2]string{d, name}] = found
+	}
+	t.mu.Unlock()
+	return found
+}
+
+// duplicates returns the packages resolved from more than one location, by name.
+func (t *depTracker) duplicates() []DuplicateDep {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []DuplicateDep
+	for name, dirs := range t.copies {
+		if len(dirs) < 2 {
+			continue
+		}
+		d := DuplicateDep{Name: name}
+		for dir, importers := range dirs {
+			pkg, _ := readPackageJSON(dir)
+			d.Copies = append(d.Copies, PackageCopy{Dir: dir, Version: pkg.Version, Importers: len(importers)})
+		}
+		sort.Slice(d.Copies, func(i, j int) bool { return d.Copies[i].Dir < d.Copies[j].Dir })
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
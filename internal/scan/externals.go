@@ -0,0 +1,34 @@
+package scan
+
+import (
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/glob"
+)
+
+// dropExternal reports whether the resolved target to should be left out of the
+// graph: it is a "pkg:" node, ExcludeExternals is set, and the package matches none
+// of ExternalsAllowlist.
+func (c Config) dropExternal(to string) bool {
+	spec, ok := strings.CutPrefix(to, "pkg:")
+	if !ok || !c.ExcludeExternals {
+		return false
+	}
+	name := PackageName(spec)
+	for _, pat := range c.ExternalsAllowlist {
+		if glob.Match(pat, name) || glob.Match(pat, spec) {
+			return false
+		}
+	}
+	return true
+}
+
+// PackageName trims a bare specifier to its package: "react/jsx-runtime" -> "react",
+// "@acme/ui/button" -> "@acme/ui".
+func PackageName(spec string) string {
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
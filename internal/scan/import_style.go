@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// reImportTyped is reImportStmt with the "type" keyword captured.
+var reImportTyped = regexp.MustCompile(`(?s)\bimport\s+(type\s+)?([^'";]*?)\s*from\s*['"]([^'"]+)['"]`)
+
+// ImportBinding is what one import statement binds from its module.
+type ImportBinding struct {
+	Spec      string
+	Default   bool     // import X from / import X, { ... } from
+	Named     []string // imported names, before any "as"
+	Namespace bool     // import * as NS from
+	TypeOnly  bool     // import type ...
+}
+
+// ParseImportBindings returns the bindings of every "import ... from" statement in
+// content, in source order. Side-effect imports and require() bind nothing and are
+// left out.
+func ParseImportBindings(content string) []ImportBinding {
+	content = reComments.ReplaceAllString(content, "")
+	var out []ImportBinding
+	for _, m := range reImportTyped.FindAllStringSubmatch(content, -1) {
+		clause := strings.TrimSpace(m[2])
+		b := ImportBinding{Spec: m[3], TypeOnly: m[1] != ""}
+		head := clause
+		if i := strings.Index(clause, "{"); i >= 0 {
+			head = clause[:i]
+			for _, n := range splitNames(clause[i:]) {
+				b.Named = append(b.Named, n.from)
+			}
+		}
+		for _, part := range strings.Split(head, ",") {
+			switch part = strings.TrimSpace(part); {
+			case part == "":
+			case strings.HasPrefix(part, "*"):
+				b.Namespace = true
+			default:
+				b.Default = true
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// DuplicateImport is a file importing the same specifier in several statements.
+type DuplicateImport struct {
+	File  string
+	Spec  string
+	Count int // value (non-type) import statements for Spec
+}
+
+// MixedImport is a module some files import as a default and others only by name.
+type MixedImport struct {
+	Module  string   // the graph node the specs resolved to
+	Default []string // files importing its default export
+	Named   []string // files importing only named exports from it
+}
+
+// ImportStyle is the import consistency report of CheckImportStyle.
+type ImportStyle struct {
+	Duplicates []DuplicateImport
+	Mixed      []MixedImport
+}
+
+// CheckImportStyle reads the source of every internal file in g (at its node path)
+// and reports files importing one specifier in more than one statement, and modules
+// imported as a default in some files but only by name in others. Type-only imports
+// are ignored, since splitting them out is a convention rather than a smell. Specs
+// are matched to modules through the edge specs, so graphs without them only get
+// the duplicates check; unreadable files are skipped.
+func CheckImportStyle(g *graph.Graph) ImportStyle {
+	var report ImportStyle
+	defaults := map[string]map[string]bool{} // module -> files importing its default
+	named := map[string]map[string]bool{}    // module -> files importing names only
+	for _, file := range g.Nodes() {
+		if graph.KindOf(file) != graph.KindInternal {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		targets := map[string]string{}
+		for _, to := range g.OutNeighbors(file) {
+			for _, spec := range g.Specs(file, to) {
+				targets[spec] = to
+			}
+		}
+		count := map[string]int{}
+		useDefault := map[string]bool{}
+		useNamed := map[string]bool{}
+		var order []string
+		for _, b := range ParseImportBindings(string(preprocess(file, NormalizeSource(data)))) {
+			if b.TypeOnly {
+				continue
+			}
+			if count[b.Spec] == 0 {
+				order = append(order, b.Spec)
+			}
+			count[b.Spec]++
+			useDefault[b.Spec] = useDefault[b.Spec] || b.Default
+			useNamed[b.Spec] = useNamed[b.Spec] || len(b.Named) > 0
+		}
+		for _, spec := range order {
+			if count[spec] > 1 {
+				report.Duplicates = append(report.Duplicates, DuplicateImport{File: file, Spec: spec, Count: count[spec]})
+			}
+			to, ok := targets[spec]
+			if !ok {
+				continue
+			}
+			switch {
+			case useDefault[spec]:
+				addFile(defaults, to, file)
+			case useNamed[spec]:
+				addFile(named, to, file)
+			}
+		}
+	}
+	for module, files := range defaults {
+		if len(named[module]) > 0 {
+			report.Mixed = append(report.Mixed, MixedImport{Module: module, Default: sortedKeys(files), Named: sortedKeys(named[module])})
+		}
+	}
+	sort.Slice(report.Mixed, func(i, j int) bool { return report.Mixed[i].Module < report.Mixed[j].Module })
+	return report
+}
+
+func addFile(m map[string]map[string]bool, module, file string) {
+	if m[module] == nil {
+		m[module] = map[string]bool{}
+	}
+	m[module][file] = true
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
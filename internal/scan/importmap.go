@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ImportMap is a browser import map: bare specifiers mapped to URLs or paths, at
+// the top level ("imports") or only for importers under a prefix ("scopes"). Keys
+// ending in "/" map every spec under that prefix.
+type ImportMap struct {
+	Imports map[string]string            `json:"imports"`
+	Scopes  map[string]map[string]string `json:"scopes"`
+
+	root string // what "/..." paths are relative to
+	dir  string // what "./..." and "../..." paths are relative to: the map's directory
+}
+
+// LoadImportMap reads an import map from path. Targets and scope prefixes
+// starting with "/" are taken relative to root (the site root), and "./" or
+// "../" ones relative to the map file.
+func LoadImportMap(root, path string) (*ImportMap, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var im ImportMap
+	if err := json.Unmarshal(b, &im); err != nil {
+		return nil, fmt.Errorf("import map %s: %w", path, err)
+	}
+	im.root, im.dir = root, filepath.Dir(path)
+	return &im, nil
+}
+
+// localPath maps an import map URL to a file path, or "" when it isn't local
+// (e.g. "https://cdn...").
+func (im *ImportMap) localPath(u string) string {
+	switch {
+	case strings.HasPrefix(u, "/"):
+		return filepath.Join(im.root, filepath.FromSlash(u))
+	case strings.HasPrefix(u, "./"), strings.HasPrefix(u, "../"):
+		return filepath.Join(im.dir, filepath.FromSlash(u))
+	}
+	return ""
+}
+
+// target returns what spec maps to for an importer in fromFile: the most specific
+// scope containing fromFile is tried first, then wider ones, then "imports".
+func (im *ImportMap) target(fromFile, spec string) (string, bool) {
+	scopes := make([]string, 0, len(im.Scopes))
+	for prefix := range im.Scopes {
+		if dir := im.localPath(prefix); dir != "" && within(dir, fromFile) {
+			scopes = append(scopes, prefix)
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool { return len(scopes[i]) > len(scopes[j]) })
+	for _, prefix := range scopes {
+		if to, ok := matchSpecMap(im.Scopes[prefix], spec); ok {
+			return to, true
+		}
+	}
+	return matchSpecMap(im.Imports, spec)
+}
+
+// matchSpecMap looks spec up in an import map "imports" object: an exact key
+// wins, otherwise the longest "/"-terminated key prefixing spec.
+func matchSpecMap(m map[string]string, spec string) (string, bool) {
+	if to, ok := m[spec]; ok {
+		return to, true
+	}
+	best := ""
+	for key := range m {
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(spec, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return m[best] + spec[len(best):], true
+}
+
+// resolveImportMap resolves a bare spec through the import map when it maps to a
+// local file. Specs mapped to remote URLs are left to the rest of resolution.
+func (r *Resolver) resolveImportMap(fromFile, spec string) (string, bool) {
+	if r.ImportMap == nil {
+		return "", false
+	}
+	target, ok := r.ImportMap.target(fromFile, spec)
+	if !ok {
+		return "", false
+	}
+	path := r.ImportMap.localPath(target)
+	if path == "" {
+		return "", false
+	}
+	if to := r.probe(path); to != "" {
+		return to, true
+	}
+	return "", false
+}
@@ -0,0 +1,42 @@
+package scan
+
+import (
+	"path/filepath"
+
+	"github.com/philjestin/philtographer/internal/glob"
+)
+
+// LayerRule puts files matching Pattern (a glob over the root-relative path, e.g.
+// "src/components/**") into the architectural layer Layer.
+type LayerRule struct {
+	Pattern string `mapstructure:"pattern" json:"pattern" yaml:"pattern"`
+	Layer   string `mapstructure:"layer" json:"layer" yaml:"layer"`
+}
+
+// LayerOf returns the layer of the first rule in Layers matching path, or "" if
+// none does.
+func (c Config) LayerOf(path string) string {
+	rel := path
+	if r, err := filepath.Rel(c.Root, path); err == nil {
+		rel = r
+	}
+	for _, rule := range c.Layers {
+		if glob.Match(rule.Pattern, rel) {
+			return rule.Layer
+		}
+	}
+	return ""
+}
+
+// LayerNames returns the distinct layers named in Layers, in rule order.
+func (c Config) LayerNames() []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, rule := range c.Layers {
+		if !seen[rule.Layer] {
+			seen[rule.Layer] = true
+			out = append(out, rule.Layer)
+		}
+	}
+	return out
+}
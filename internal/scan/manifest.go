@@ -0,0 +1,43 @@
+package scan
+
+// Manifest records what a scan saw beyond the graph itself, so callers can report
+// on coverage without re-walking the tree.
+type Manifest struct {
+	Files      int          // source files read and parsed
+	Unresolved []Unresolved // relative specs that could not be resolved to a file
+	Skipped    []Skipped    // files deliberately left out of the graph
+	OutOfRoot  []OutOfRoot  // imports resolving outside the root (only with Config.ConfineRoot)
+	SelfImport []SelfImport // imports resolving to the importing file itself (no edge is added)
+	// Computed lists import()/require() calls with a runtime-computed argument,
+	// whose edges the graph can't contain.
+	Computed []ComputedImport
+	// AmbiguousIndexes lists directories imported as modules that have several
+	// index files, once each; resolution picked the first in extension order.
+	AmbiguousIndexes []AmbiguousIndex
+	// Resolution breaks down how the scan's specifiers were resolved.
+	Resolution ResolveStats
+	// DuplicateDeps lists packages imported from more than one node_modules
+	// location (only with Config.DuplicateDeps).
+	DuplicateDeps []DuplicateDep
+}
+
+// OutOfRoot is an import that resolved to a file outside the scan root, either
+// lexically (../../..) or through a symlink.
+type OutOfRoot struct {
+	File string
+	Spec string
+	To   string
+}
+
+// Skipped is a file the scan chose not to parse, and why.
+type Skipped struct {
+	File   string
+	Reason string
+}
+
+// SelfImport is an import that resolved back to the file containing it, usually a
+// barrel importing itself through an alias.
+type SelfImport struct {
+	File string
+	Spec string
+}
@@ -0,0 +1,162 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/graph"
+)
+
+// packageFileExtensions are probed, in order, for extensionless relative imports
+// inside an installed package.
+var packageFileExtensions = []string{".js", ".mjs", ".cjs"}
+
+// installed returns the directories recorded for each package, by name, sorted.
+func (t *depTracker) installed() map[string][]string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string][]string, len(t.copies))
+	for name, dirs := range t.copies {
+		for dir := range dirs {
+			out[name] = append(out[name], dir)
+		}
+		sort.Strings(out[name])
+	}
+	return out
+}
+
+// addNodeModuleEdges records package-to-package edges ("pkg:a" -> "pkg:b") for the
+// installed packages in pkgs, the ones workspace files import, by reading each
+// package's own source from its entry point. Packages those import are followed
+// the same way up to cfg.NodeModulesDepth levels. Edges go to add.
+func addNodeModuleEdges(ctx context.Context, cfg Config, pkgs *depTracker, add func(from, to string)) {
+	type pkgDir struct{ name, dir string }
+	var level []pkgDir
+	seen := map[string]bool{}
+	for name, dirs := range pkgs.installed() {
+		for _, dir := range dirs {
+			seen[dir] = true
+			level = append(level, pkgDir{name, dir})
+		}
+	}
+	for depth := 1; depth <= cfg.NodeModulesDepth && len(level) > 0; depth++ {
+		var next []pkgDir
+		for _, p := range level {
+			if ctx.Err() != nil {
+				return
+			}
+			for _, dep := range packageImports(p.dir) {
+				to := "pkg:" + dep
+				if dep == p.name || cfg.dropExternal(to) {
+					continue
+				}
+				add("pkg:"+p.name, to)
+				if dir := pkgs.locate(p.dir, dep); dir != "" && !seen[dir] {
+					seen[dir] = true
+					next = append(next, pkgDir{dep, dir})
+				}
+			}
+		}
+		level = next
+	}
+}
+
+// packageImports returns the packages imported by the installed package in dir,
+// following its relative imports from the entry point (package.json "module",
+// then "main", then index.js) without leaving dir. Sorted.
+func packageImports(dir string) []string {
+	pkg, _ := readPackageJSON(dir)
+	var queue []string
+	for _, entry := range []string{pkg.Module, pkg.Main, "index.js"} {
+		if entry == "" {
+			continue
+		}
+		if f := probePackageFile(dir, filepath.Join(dir, entry)); f != "" {
+			queue = append(queue, f)
+			break
+		}
+	}
+	visited := map[string]bool{}
+	deps := map[string]bool{}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if visited[file] {
+			continue
+		}
+		visited[file] = true
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, spec := range ParseImports(string(data)) {
+			switch {
+			case IsApproximate(spec):
+			case isRelativeImport(spec):
+				spec, _ = splitSpecSuffix(spec)
+				if f := probePackageFile(dir, filepath.Join(filepath.Dir(file), spec)); f != "" {
+					queue = append(queue, f)
+				}
+			case strings.HasPrefix(spec, "#") || strings.HasPrefix(spec, "/"):
+			default:
+				deps[PackageName(spec)] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(deps))
+	for d := range deps {
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// probePackageFile resolves cand to a file inside the package directory dir: as
+// is, with one of packageFileExtensions, or as a directory's index. It returns ""
+// for anything that doesn't exist or lies outside dir.
+func probePackageFile(dir, cand string) string {
+	cand = filepath.Clean(cand)
+	if !within(dir, cand) {
+		return ""
+	}
+	for _, c := range packageFileCandidates(cand) {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// packageFileCandidates lists the paths cand may name inside a package, in the
+// order they are probed: as is, with each of packageFileExtensions, then as a
+// directory's index.
+func packageFileCandidates(cand string) []string {
+	candidates := []string{cand}
+	for _, ext := range packageFileExtensions {
+		candidates = append(candidates, cand+ext)
+	}
+	for _, ext := range packageFileExtensions {
+		candidates = append(candidates, filepath.Join(cand, "index"+ext))
+	}
+	return candidates
+}
+
+// nodeModuleEdgeAdder returns the add func for addNodeModuleEdges: edges are
+// streamed to cfg.OnEdge when set, or stored in g.
+func nodeModuleEdgeAdder(cfg Config, g *graph.Graph) func(from, to string) {
+	return func(from, to string) {
+		if cfg.OnEdge != nil {
+			g.Touch(from)
+			g.Touch(to)
+			cfg.OnEdge(from, to, "", "")
+			return
+		}
+		g.AddEdge(from, to)
+	}
+}
@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageJSON models the subset of package.json we care about.
+type packageJSON struct {
+	Name    string                     `json:"name"`
+	Version string                     `json:"version"`
+	Main    string                     `json:"main"`
+	Module  string                     `json:"module"`
+	Imports map[string]json.RawMessage `json:"imports"`
+	// Workspaces is a list of globs, or {"packages": [...]} in older Yarn.
+	Workspaces json.RawMessage `json:"workspaces"`
+}
+
+// importConditions is the order in which conditional "imports" targets are tried.
+var importConditions = []string{"types", "import", "module", "require", "node", "default"}
+
+// resolvePackageImport resolves a "#subpath" spec using the "imports" field of the
+// nearest package.json above fromFile (stopping at the resolver root).
+func (r *Resolver) resolvePackageImport(fromFile, spec string) (string, bool) {
+	dir := filepath.Dir(fromFile)
+	for {
+		if pkg, ok := readPackageJSON(dir); ok {
+			// The nearest package.json owns the "#" namespace even if it has no match.
+			if len(pkg.Imports) == 0 {
+				return "", false
+			}
+			return r.resolveImportsField(dir, pkg.Imports, spec)
+		}
+		if dir == r.root || dir == filepath.Dir(dir) {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	return "", false
+}
+
+// readPackageJSON reads package.json in dir.
+func readPackageJSON(dir string) (packageJSON, bool) {
+	var pkg packageJSON
+	b, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return pkg, false
+	}
+	if json.Unmarshal(b, &pkg) != nil {
+		return pkg, false
+	}
+	return pkg, true
+}
+
+// resolveImportsField matches spec against an "imports" map (exact keys first, then
+// single-"*" patterns, longest prefix before the "*" first as in Node) and probes the
+// target relative to pkgDir.
+func (r *Resolver) resolveImportsField(pkgDir string, imports map[string]json.RawMessage, spec string) (string, bool) {
+	if raw, ok := imports[spec]; ok {
+		if to := r.probeImportTarget(pkgDir, raw, ""); to != "" {
+			return to, true
+		}
+	}
+	var patterns []string
+	for pat := range imports {
+		if strings.Contains(pat, "*") {
+			patterns = append(patterns, pat)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		a, b := strings.Index(patterns[i], "*"), strings.Index(patterns[j], "*")
+		if a != b {
+			return a > b
+		}
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pat := range patterns {
+		raw := imports[pat]
+		i := strings.Index(pat, "*")
+		head, tail := pat[:i], pat[i+1:]
+		if !strings.HasPrefix(spec, head) || !strings.HasSuffix(spec, tail) || len(spec) < len(head)+len(tail) {
+			continue
+		}
+		match := spec[len(head) : len(spec)-len(tail)]
+		if to := r.probeImportTarget(pkgDir, raw, match); to != "" {
+			return to, true
+		}
+	}
+	return "", false
+}
+
+// probeImportTarget handles string targets and conditional target objects,
+// substituting match for "*" before probing.
+func (r *Resolver) probeImportTarget(pkgDir string, raw json.RawMessage, match string) string {
+	var target string
+	if json.Unmarshal(raw, &target) == nil {
+		if !strings.HasPrefix(target, "./") {
+			// Targets mapping to other packages are left to bare resolution.
+			return ""
+		}
+		return r.resolveFromBaseDir(pkgDir, strings.ReplaceAll(target, "*", match))
+	}
+	var conds map[string]json.RawMessage
+	if json.Unmarshal(raw, &conds) == nil {
+		for _, c := range importConditions {
+			if sub, ok := conds[c]; ok {
+				if to := r.probeImportTarget(pkgDir, sub, match); to != "" {
+					return to
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,365 @@
+package scan
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PnP is the dependency map of a Yarn Plug'n'Play install: which package owns
+// each directory, what each package's dependencies resolve to, and where every
+// package lives. Locations are usually inside the zip archives under .yarn/cache;
+// files in them are probed without extracting anything. It is safe for
+// concurrent use.
+type PnP struct {
+	packages map[pnpLocator]pnpPackage
+	owners   []pnpOwner             // package locations, longest first
+	fallback map[string]*pnpLocator // top-level dependencies any package may use, when enabled
+
+	mu      sync.Mutex
+	zips    map[string]map[string]bool // archive -> the files in it
+	entries map[string]string          // package dir -> its entry file ("" if none)
+}
+
+// pnpLocator identifies one package instance; the top-level workspace is {"", ""}.
+type pnpLocator struct{ name, ref string }
+
+type pnpPackage struct {
+	dir string // absolute, with virtual paths mapped to real ones
+	// deps maps dependency names to the package each resolves to; nil marks a
+	// declared but missing one (an unmet peer dependency).
+	deps map[string]*pnpLocator
+}
+
+type pnpOwner struct {
+	dir     string
+	locator pnpLocator
+}
+
+// pnpData is the subset of Yarn's PnP runtime state we read.
+type pnpData struct {
+	EnableTopLevelFallback bool                `json:"enableTopLevelFallback"`
+	FallbackPool           [][]json.RawMessage `json:"fallbackPool"`
+	PackageRegistryData    []json.RawMessage   `json:"packageRegistryData"`
+}
+
+type pnpPackageInfo struct {
+	PackageLocation     string              `json:"packageLocation"`
+	PackageDependencies [][]json.RawMessage `json:"packageDependencies"`
+}
+
+// LoadPnP reads the Plug'n'Play data for root: .pnp.data.json when Yarn writes it
+// on its own (pnpEnableInlining: false), else the state inlined in .pnp.cjs.
+func LoadPnP(root string) (*PnP, error) {
+	path := filepath.Join(root, ".pnp.data.json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		path = filepath.Join(root, ".pnp.cjs")
+		var src []byte
+		if src, err = os.ReadFile(path); err == nil {
+			var ok bool
+			if data, ok = inlinedPnPState(src); !ok {
+				return nil, fmt.Errorf("pnp %s: no RAW_RUNTIME_STATE found", path)
+			}
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("pnp: no .pnp.data.json or .pnp.cjs in %s", root)
+		}
+		return nil, err
+	}
+	p, err := parsePnP(root, data)
+	if err != nil {
+		return nil, fmt.Errorf("pnp %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// parsePnP builds a PnP from the JSON runtime state, with package locations
+// taken relative to root.
+func parsePnP(root string, data []byte) (*PnP, error) {
+	var state pnpData
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	p := &PnP{
+		packages: map[pnpLocator]pnpPackage{},
+		zips:     map[string]map[string]bool{},
+		entries:  map[string]string{},
+	}
+	// Each registry entry is [name, [[reference, info], ...]], with null for the
+	// top-level workspace's name and reference.
+	for _, raw := range state.PackageRegistryData {
+		var entry []json.RawMessage
+		if err := json.Unmarshal(raw, &entry); err != nil || len(entry) != 2 {
+			return nil, fmt.Errorf("malformed packageRegistryData entry %s", raw)
+		}
+		var name *string
+		var refs [][]json.RawMessage
+		if json.Unmarshal(entry[0], &name) != nil || json.Unmarshal(entry[1], &refs) != nil {
+			return nil, fmt.Errorf("malformed packageRegistryData entry %s", raw)
+		}
+		for _, pair := range refs {
+			var ref *string
+			var info pnpPackageInfo
+			if len(pair) != 2 || json.Unmarshal(pair[0], &ref) != nil || json.Unmarshal(pair[1], &info) != nil {
+				return nil, fmt.Errorf("malformed package %s", pair)
+			}
+			loc := pnpLocator{deref(name), deref(ref)}
+			dir := filepath.Join(root, filepath.FromSlash(info.PackageLocation))
+			p.packages[loc] = pnpPackage{dir: devirtualize(dir), deps: pnpDeps(info.PackageDependencies)}
+			p.owners = append(p.owners, pnpOwner{dir: dir, locator: loc})
+		}
+	}
+	sort.SliceStable(p.owners, func(i, j int) bool { return len(p.owners[i].dir) > len(p.owners[j].dir) })
+	if state.EnableTopLevelFallback {
+		p.fallback = map[string]*pnpLocator{}
+		for name, dep := range p.packages[pnpLocator{}].deps {
+			p.fallback[name] = dep
+		}
+		for name, dep := range pnpDeps(state.FallbackPool) {
+			p.fallback[name] = dep
+		}
+	}
+	return p, nil
+}
+
+// pnpDeps decodes [name, reference] pairs. A reference is a string, null for a
+// missing peer, or [name, reference] for an aliased package ("foo": "npm:bar@1").
+func pnpDeps(pairs [][]json.RawMessage) map[string]*pnpLocator {
+	out := make(map[string]*pnpLocator, len(pairs))
+	for _, pair := range pairs {
+		var name string
+		if len(pair) != 2 || json.Unmarshal(pair[0], &name) != nil {
+			continue
+		}
+		var ref *string
+		var alias []string
+		switch {
+		case json.Unmarshal(pair[1], &ref) == nil:
+			if ref == nil {
+				out[name] = nil
+			} else {
+				out[name] = &pnpLocator{name, *ref}
+			}
+		case json.Unmarshal(pair[1], &alias) == nil && len(alias) == 2:
+			out[name] = &pnpLocator{alias[0], alias[1]}
+		}
+	}
+	return out
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// devirtualize maps a path through Yarn's virtual folder,
+// <dir>/__virtual__/<hash>/<n>/<rest>, to the real path: <rest> under the
+// directory n levels above <dir>. Other paths are returned as is.
+func devirtualize(path string) string {
+	sep := string(filepath.Separator)
+	marker := sep + "__virtual__" + sep
+	i := strings.Index(path, marker)
+	if i < 0 {
+		return path
+	}
+	parts := strings.SplitN(path[i+len(marker):], sep, 3)
+	if len(parts) < 2 {
+		return path
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return path
+	}
+	target := path[:i]
+	for range n {
+		target = filepath.Dir(target)
+	}
+	if len(parts) == 3 {
+		target = filepath.Join(target, parts[2])
+	}
+	return devirtualize(target)
+}
+
+// inlinedPnPState extracts the JSON assigned to RAW_RUNTIME_STATE in a .pnp.cjs,
+// a single-quoted JS string literal.
+func inlinedPnPState(src []byte) ([]byte, bool) {
+	i := bytes.Index(src, []byte("RAW_RUNTIME_STATE"))
+	if i < 0 {
+		return nil, false
+	}
+	src = src[i:]
+	start := bytes.IndexByte(src, '\'')
+	if start < 0 {
+		return nil, false
+	}
+	var out []byte
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '\'':
+			return out, true
+		case '\\':
+			i++
+			if i == len(src) {
+				return nil, false
+			}
+			switch e := src[i]; e {
+			case '\n': // line continuation
+			case 'n':
+				out = append(out, '\n')
+			case 't':
+				out = append(out, '\t')
+			case 'r':
+				out = append(out, '\r')
+			default:
+				out = append(out, e)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return nil, false
+}
+
+// resolve maps a bare spec imported by fromFile to a file in the package it
+// names, through the dependencies of the package owning fromFile and then the
+// top-level fallback. It returns false when that package doesn't depend on the
+// spec's package or the file doesn't exist.
+func (p *PnP) resolve(fromFile, spec string) (string, bool) {
+	name := PackageName(spec)
+	var dep *pnpLocator
+	declared := false
+	for _, o := range p.owners {
+		if within(o.dir, fromFile) {
+			dep, declared = p.packages[o.locator].deps[name]
+			break
+		}
+	}
+	if !declared && p.fallback != nil {
+		dep, declared = p.fallback[name]
+	}
+	if dep == nil {
+		return "", false
+	}
+	pkg, ok := p.packages[*dep]
+	if !ok {
+		return "", false
+	}
+	if rest := strings.TrimPrefix(spec[len(name):], "/"); rest != "" {
+		to := p.probe(pkg.dir, filepath.Join(pkg.dir, filepath.FromSlash(rest)))
+		return to, to != ""
+	}
+	to := p.entry(pkg.dir)
+	return to, to != ""
+}
+
+// entry returns the entry file of the package in dir: package.json "module",
+// then "main", then index.js.
+func (p *PnP) entry(dir string) string {
+	p.mu.Lock()
+	to, ok := p.entries[dir]
+	p.mu.Unlock()
+	if ok {
+		return to
+	}
+	var pkg packageJSON
+	if data, err := p.readFile(filepath.Join(dir, "package.json")); err == nil {
+		_ = json.Unmarshal(data, &pkg)
+	}
+	for _, entry := range []string{pkg.Module, pkg.Main, "index.js"} {
+		if entry == "" {
+			continue
+		}
+		if to = p.probe(dir, filepath.Join(dir, entry)); to != "" {
+			break
+		}
+	}
+	p.mu.Lock()
+	p.entries[dir] = to
+	p.mu.Unlock()
+	return to
+}
+
+// probe is probePackageFile for packages that may live in zip archives.
+func (p *PnP) probe(dir, cand string) string {
+	cand = filepath.Clean(cand)
+	if !within(dir, cand) {
+		return ""
+	}
+	for _, c := range packageFileCandidates(cand) {
+		if p.isFile(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// splitZip splits a path through a zip archive (.../x.zip/node_modules/x/a.js)
+// into the archive and the slash-separated name inside it.
+func splitZip(path string) (archive, name string, ok bool) {
+	slashed := filepath.ToSlash(path)
+	i := strings.Index(slashed, ".zip/")
+	if i < 0 {
+		return "", "", false
+	}
+	return path[:i+len(".zip")], slashed[i+len(".zip/"):], true
+}
+
+func (p *PnP) isFile(path string) bool {
+	archive, name, ok := splitZip(path)
+	if !ok {
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir()
+	}
+	return p.zipFiles(archive)[name]
+}
+
+// zipFiles lists the files in archive, once; an unreadable archive has none.
+func (p *PnP) zipFiles(archive string) map[string]bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if files, ok := p.zips[archive]; ok {
+		return files
+	}
+	files := map[string]bool{}
+	if r, err := zip.OpenReader(archive); err == nil {
+		for _, f := range r.File {
+			if !f.FileInfo().IsDir() {
+				files[f.Name] = true
+			}
+		}
+		r.Close()
+	}
+	p.zips[archive] = files
+	return files
+}
+
+func (p *PnP) readFile(path string) ([]byte, error) {
+	archive, name, ok := splitZip(path)
+	if !ok {
+		return os.ReadFile(path)
+	}
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
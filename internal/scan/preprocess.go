@@ -0,0 +1,60 @@
+package scan
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Preprocessor turns the raw contents of a file that isn't plain JS/TS (a Vue or
+// Svelte single-file component, say) into JS/TS that ParseImports can read.
+type Preprocessor func(src []byte) []byte
+
+var (
+	preprocessorsMu sync.RWMutex
+	preprocessors   = map[string]Preprocessor{
+		".vue":    ExtractScripts,
+		".svelte": ExtractScripts,
+	}
+)
+
+// RegisterPreprocessor makes files with extension ext (e.g. ".mdx") part of full
+// scans, passing their contents through p before imports are parsed. It replaces
+// any preprocessor already registered for ext, including the built-in ones.
+func RegisterPreprocessor(ext string, p Preprocessor) {
+	preprocessorsMu.Lock()
+	defer preprocessorsMu.Unlock()
+	preprocessors[strings.ToLower(ext)] = p
+}
+
+// preprocessorFor returns the preprocessor registered for path's extension.
+func preprocessorFor(path string) (Preprocessor, bool) {
+	preprocessorsMu.RLock()
+	defer preprocessorsMu.RUnlock()
+	p, ok := preprocessors[strings.ToLower(filepath.Ext(path))]
+	return p, ok
+}
+
+// preprocess returns data as parseable JS/TS: unchanged for plain sources, or run
+// through the preprocessor registered for path's extension.
+func preprocess(path string, data []byte) []byte {
+	if p, ok := preprocessorFor(path); ok {
+		return p(data)
+	}
+	return data
+}
+
+var reScriptBlock = regexp.MustCompile(`(?is)<script\b[^>]*>(.*?)</script\s*>`)
+
+// ExtractScripts returns the contents of every <script> block in an SFC (Vue's
+// <script> and <script setup>, Svelte's instance and module scripts), joined by
+// newlines. Markup and styles are dropped.
+func ExtractScripts(src []byte) []byte {
+	var out [][]byte
+	for _, m := range reScriptBlock.FindAllSubmatch(src, -1) {
+		out = append(out, m[1])
+	}
+	return bytes.Join(out, []byte("\n"))
+}
@@ -2,20 +2,62 @@ package providers
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"strings"
 
+	"github.com/philjestin/philtographer/internal/glob"
 	"github.com/philjestin/philtographer/internal/scan"
 )
 
+// ExplicitProvider yields the entry at Path. A directory resolves to its
+// index.{tsx,ts,jsx,js}; a glob (e.g. "apps/*/src") expands to one entry per match,
+// named "<Name>:<relative path>" (or just the relative path when Name is empty).
 type ExplicitProvider struct {
 	Name string
 	Path string
 }
 
 func (e ExplicitProvider) Discover(ctx context.Context, workspaceRoot string) ([]scan.Entry, error) {
+	if glob.IsPattern(e.Path) {
+		matches, err := glob.Expand(workspaceRoot, e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("expand %q: %w", e.Path, err)
+		}
+		var entries []scan.Entry
+		for _, m := range matches {
+			p := resolveTSXPath(m)
+			if p == "" || !isSourceFile(p) {
+				// matched something that isn't a source file or a dir with an index
+				continue
+			}
+			name := m
+			if rel, err := filepath.Rel(workspaceRoot, m); err == nil {
+				name = filepath.ToSlash(rel)
+			}
+			if e.Name != "" {
+				name = e.Name + ":" + name
+			}
+			entries = append(entries, scan.Entry{Name: name, Path: p})
+		}
+		return entries, nil
+	}
+
 	p := e.Path
 	if !filepath.IsAbs(p) {
 		p = filepath.Clean(filepath.Join(workspaceRoot, p))
 	}
+	// Directories resolve to their index file; keep the path as-is if nothing matches.
+	if resolved := resolveTSXPath(p); resolved != "" {
+		p = resolved
+	}
 	return []scan.Entry{{Name: e.Name, Path: p}}, nil
 }
+
+func isSourceFile(p string) bool {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".ts", ".tsx", ".js", ".jsx":
+		return true
+	}
+	return false
+}
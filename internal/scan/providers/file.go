@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+// FileProvider yields the entries listed in File, e.g. a manifest generated by
+// another build tool. File is either a JSON array of {"name", "path"} objects or
+// plain text with one path per line (blank lines and "#" comments are skipped,
+// and entries are named by their path relative to the workspace root).
+//
+// File and the paths in it are relative to the workspace root unless absolute;
+// directories resolve to their index file, as for ExplicitProvider.
+type FileProvider struct {
+	File string
+}
+
+// fileEntry is one element of a JSON entries file.
+type fileEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+func (f FileProvider) Discover(ctx context.Context, workspaceRoot string) ([]scan.Entry, error) {
+	if f.File == "" {
+		return nil, fmt.Errorf("file provider: file is required")
+	}
+	file := f.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(workspaceRoot, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read entries file: %w", err)
+	}
+
+	var listed []fileEntry
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &listed); err != nil {
+			return nil, fmt.Errorf("parse entries file %s: %w", f.File, err)
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			listed = append(listed, fileEntry{Path: line})
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("read entries file %s: %w", f.File, err)
+		}
+	}
+
+	entries := make([]scan.Entry, 0, len(listed))
+	for i, e := range listed {
+		if e.Path == "" {
+			return nil, fmt.Errorf("entries file %s: entry %d has no path", f.File, i+1)
+		}
+		p := e.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Clean(filepath.Join(workspaceRoot, p))
+		}
+		if resolved := resolveTSXPath(p); resolved != "" {
+			p = resolved
+		}
+		name := e.Name
+		if name == "" {
+			name = filepath.ToSlash(e.Path)
+			if rel, err := filepath.Rel(workspaceRoot, p); err == nil {
+				name = filepath.ToSlash(rel)
+			}
+		}
+		entries = append(entries, scan.Entry{Name: name, Path: p})
+	}
+	return entries, nil
+}
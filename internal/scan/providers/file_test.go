@@ -0,0 +1,41 @@
+package providers
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	checkout := write(t, filepath.Join(dir, "src", "checkout.tsx"), "export default 1")
+	admin := write(t, filepath.Join(dir, "src", "admin", "index.ts"), "export default 1")
+
+	write(t, filepath.Join(dir, "entries.txt"), "# generated\nsrc/checkout.tsx\n\n  src/admin  \n")
+	got, err := FileProvider{File: "entries.txt"}.Discover(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []scan.Entry{{Name: "src/checkout.tsx", Path: checkout}, {Name: "src/admin/index.ts", Path: admin}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("text: got %v, want %v", got, want)
+	}
+
+	write(t, filepath.Join(dir, "entries.json"), `[{"name": "Checkout", "path": "src/checkout.tsx"}, {"path": "src/admin"}]`)
+	got, err = FileProvider{File: filepath.Join(dir, "entries.json")}.Discover(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []scan.Entry{{Name: "Checkout", Path: checkout}, {Name: "src/admin/index.ts", Path: admin}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("json: got %v, want %v", got, want)
+	}
+
+	write(t, filepath.Join(dir, "bad.json"), `[{"name": "NoPath"}]`)
+	if _, err := (FileProvider{File: "bad.json"}).Discover(context.Background(), dir); err == nil {
+		t.Fatal("expected an error for an entry without a path")
+	}
+}
@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitChanged returns the files changed in the git repository containing dir
+// since it diverged from baseRef: committed changes since the merge base plus
+// uncommitted ones in the working tree. Paths are absolute.
+func GitChanged(ctx context.Context, dir, baseRef string) ([]string, error) {
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	base, err := git(ctx, dir, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	names, err := git(ctx, dir, "diff", "--name-only", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, name := range strings.Split(names, "\n") {
+		if name != "" {
+			out = append(out, filepath.Join(top, filepath.FromSlash(name)))
+		}
+	}
+	return out, nil
+}
+
+// git runs a git subcommand in dir and returns its trimmed stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	write(t, filepath.Join(dir, "a.ts"), "export const a = 1")
+	write(t, filepath.Join(dir, "b.ts"), "export const b = 1")
+	run("add", "-A")
+	run("commit", "-q", "-m", "base")
+	run("checkout", "-q", "-b", "feature")
+	write(t, filepath.Join(dir, "src", "c.ts"), "export const c = 1")
+	run("add", "-A")
+	run("commit", "-q", "-m", "add c")
+	write(t, filepath.Join(dir, "a.ts"), "export const a = 2") // uncommitted
+
+	got, err := GitChanged(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(real, "a.ts"), filepath.Join(real, "src", "c.ts")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GitChanged() = %v, want %v", got, want)
+	}
+	if _, err := GitChanged(context.Background(), dir, "no-such-ref"); err == nil {
+		t.Fatal("expected an error for an unknown ref")
+	}
+}
@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/philjestin/philtographer/internal/glob"
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+// GlobProvider yields one entry per source file matching Pattern (e.g. "**/*.route.tsx").
+//
+// Entries are named, in order of precedence:
+//  1. a webpackChunkName magic comment in the file's leading lines:
+//     /* webpackChunkName: "Checkout" */
+//  2. the "name" field of an adjacent metadata file: for foo.route.tsx, foo.route.meta.json
+//  3. the path relative to the workspace root
+//
+// Set NameFrom to "path" to always name entries by relative path.
+type GlobProvider struct {
+	Pattern  string
+	NameFrom string // "webpackChunkName" (default) or "path"
+}
+
+// chunkNameLines is how many leading lines are searched for a webpackChunkName comment.
+const chunkNameLines = 10
+
+var reChunkName = regexp.MustCompile(`/\*\s*webpackChunkName:\s*["']([^"']+)["']\s*\*/`)
+
+func (g GlobProvider) Discover(ctx context.Context, workspaceRoot string) ([]scan.Entry, error) {
+	if g.Pattern == "" {
+		return nil, fmt.Errorf("glob provider: pattern is required")
+	}
+	matches, err := glob.Expand(workspaceRoot, g.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("expand %q: %w", g.Pattern, err)
+	}
+	var entries []scan.Entry
+	for _, m := range matches {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		if info, err := os.Stat(m); err != nil || info.IsDir() || !isSourceFile(m) {
+			continue
+		}
+		name := m
+		if rel, err := filepath.Rel(workspaceRoot, m); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		if g.NameFrom != "path" {
+			if n := chunkName(m); n != "" {
+				name = n
+			} else if n := metaName(m); n != "" {
+				name = n
+			}
+		}
+		entries = append(entries, scan.Entry{Name: name, Path: m})
+	}
+	return entries, nil
+}
+
+// chunkName returns the webpackChunkName from a comment near the top of path, if any.
+func chunkName(path string) string {
+	head, err := scan.FirstLines(path, chunkNameLines)
+	if err != nil {
+		return ""
+	}
+	if m := reChunkName.FindStringSubmatch(head); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// metaName returns the "name" from path's sibling <stem>.meta.json, if any.
+func metaName(path string) string {
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	b, err := os.ReadFile(stem + ".meta.json")
+	if err != nil {
+		return ""
+	}
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(b, &meta) != nil {
+		return ""
+	}
+	return meta.Name
+}
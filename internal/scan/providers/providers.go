@@ -2,6 +2,11 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 
 	"github.com/philjestin/philtographer/internal/scan"
 )
@@ -11,3 +16,26 @@ import (
 type Provider interface {
 	Discover(ctx context.Context, workspaceRoot string) ([]scan.Entry, error)
 }
+
+// CheckEntry reports why e can't be built from: its file doesn't exist, is a
+// directory without an index file, or isn't a TS/JS source file. Relative paths are
+// taken from workspaceRoot. Providers keep such paths best-effort, so a stale entry
+// in config would otherwise just produce an empty closure.
+func CheckEntry(workspaceRoot string, e scan.Entry) error {
+	p := e.Path
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(workspaceRoot, p)
+	}
+	info, err := os.Stat(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%s does not exist", e.Path)
+	case err != nil:
+		return err
+	case info.IsDir():
+		return fmt.Errorf("%s is a directory without an index file", e.Path)
+	case !isSourceFile(p):
+		return fmt.Errorf("%s is not a TS/JS source file", e.Path)
+	}
+	return nil
+}
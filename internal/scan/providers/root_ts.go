@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/philjestin/philtographer/internal/scan"
 )
@@ -15,18 +16,59 @@ import (
 //	Name: { moduleFactory: () => import(/* webpackChunkName: "Name" */ "./components/foo/root") }
 //
 // We name entries by object key by default, optionally by webpackChunkName.
+//
+// Other registration shapes are picked with Style, or matched with a custom Regex
+// whose capture groups are, in order: the object key, the webpackChunkName (may
+// match empty), and the import path.
 type RootsTsProvider struct {
 	File     string // path to roots.ts (relative to workspace or absolute)
 	NameFrom string // "objectKey" (default) or "webpackChunkName"
+	Style    string // "moduleFactory" (default) or "call"; see rootsTsStyles
+	Regex    string // custom member regex, overriding Style
 }
 
 var (
-	// Captures: 1=ObjectKey, 2=import path, 3=optional chunkname
+	// Captures: 1=ObjectKey, 2=optional chunkname, 3=import path
 	// We keep it permissive for comments/whitespace.
 	reRootMember = regexp.MustCompile(`(?s)([A-Za-z0-9_]+)\s*:\s*{[^}]*?moduleFactory\s*:\s*\(\s*\)\s*=>\s*import\(\s*(?:/\*\s*webpackChunkName:\s*"(.*?)"\s*\*/\s*)?['"]([^'"]+)['"]\s*\)`)
+	// Same captures for members registered through a helper call:
+	//	Name: lazyRoot(() => import(/* webpackChunkName: "Name" */ "./components/foo/root"))
+	reRootCall = regexp.MustCompile(`(?s)([A-Za-z0-9_]+)\s*:\s*[A-Za-z0-9_.$]+\(\s*\(\s*\)\s*=>\s*import\(\s*(?:/\*\s*webpackChunkName:\s*"(.*?)"\s*\*/\s*)?['"]([^'"]+)['"]\s*\)`)
 )
 
+// rootsTsStyles are the member shapes RootsTsProvider.Style can name.
+var rootsTsStyles = map[string]*regexp.Regexp{
+	"moduleFactory": reRootMember, // Name: { moduleFactory: () => import("...") }
+	"call":          reRootCall,   // Name: anyHelper(() => import("..."))
+}
+
+// memberRegex returns the regex roots.ts members are matched with.
+func (r RootsTsProvider) memberRegex() (*regexp.Regexp, error) {
+	if r.Regex != "" {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("rootsTs regex: %w", err)
+		}
+		if re.NumSubexp() < 3 {
+			return nil, fmt.Errorf("rootsTs regex needs 3 capture groups (key, chunkName, importPath), has %d", re.NumSubexp())
+		}
+		return re, nil
+	}
+	if r.Style == "" {
+		return reRootMember, nil
+	}
+	re, ok := rootsTsStyles[r.Style]
+	if !ok {
+		return nil, fmt.Errorf("unknown rootsTs style %q (want moduleFactory or call)", r.Style)
+	}
+	return re, nil
+}
+
 func (r RootsTsProvider) Discover(ctx context.Context, workspaceRoot string) ([]scan.Entry, error) {
+	re, err := r.memberRegex()
+	if err != nil {
+		return nil, err
+	}
 	// Resolve path relative to workspace
 	path := r.File
 	if !filepath.IsAbs(path) {
@@ -37,10 +79,12 @@ func (r RootsTsProvider) Discover(ctx context.Context, workspaceRoot string) ([]
 		return nil, fmt.Errorf("read roots.ts: %w", err)
 	}
 
-	matches := reRootMember.FindAllStringSubmatch(string(b), -1)
+	matches := re.FindAllStringSubmatch(string(b), -1)
 	entries := make([]scan.Entry, 0, len(matches))
 
 	baseDir := filepath.Dir(path)
+	// Specs in roots.ts may be baseUrl- or alias-relative rather than file-relative.
+	resolver := scan.NewResolver(workspaceRoot)
 	for _, m := range matches {
 		objectKey := m[1]
 		chunkName := m[2]
@@ -57,7 +101,16 @@ func (r RootsTsProvider) Discover(ctx context.Context, workspaceRoot string) ([]
 		if !filepath.IsAbs(entryPath) {
 			entryPath = filepath.Clean(filepath.Join(baseDir, importRel))
 		}
-		resolved := resolveTSXPath(entryPath)
+		resolved := ""
+		if !strings.HasPrefix(importRel, ".") && !filepath.IsAbs(importRel) {
+			// Bare specs go through tsconfig paths/baseUrl; "pkg:" means nothing matched.
+			if to, err := resolver.Resolve(path, importRel); err == nil && !strings.HasPrefix(to, "pkg:") {
+				resolved = to
+			}
+		}
+		if resolved == "" {
+			resolved = resolveTSXPath(entryPath)
+		}
 		if resolved == "" {
 			// Keep best-effort path; downstream may handle resolution
 			resolved = entryPath
@@ -72,6 +125,8 @@ func (r RootsTsProvider) Discover(ctx context.Context, workspaceRoot string) ([]
 	return entries, nil
 }
 
+// resolveTSXPath probes candidate as a file, with source extensions, and as a directory
+// with an index file. TS/TSX are preferred over JS/JSX.
 func resolveTSXPath(candidate string) string {
 	try := []string{
 		candidate,
@@ -79,6 +134,10 @@ func resolveTSXPath(candidate string) string {
 		candidate + ".ts",
 		filepath.Join(candidate, "index.tsx"),
 		filepath.Join(candidate, "index.ts"),
+		candidate + ".jsx",
+		candidate + ".js",
+		filepath.Join(candidate, "index.jsx"),
+		filepath.Join(candidate, "index.js"),
 	}
 	for _, p := range try {
 		if info, err := os.Stat(p); err == nil && !info.IsDir() {
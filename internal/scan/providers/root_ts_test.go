@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/philjestin/philtographer/internal/scan"
+)
+
+func write(t *testing.T, path string, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRootsTsProvider_BaseURLRelativeSpecs(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "tsconfig.json"), `{"compilerOptions": {"baseUrl": "src"}}`)
+	write(t, filepath.Join(dir, "src", "roots.ts"), `
+        export const roots = {
+          Foo: { moduleFactory: () => import("components/foo/root") },
+          Bar: { moduleFactory: () => import("./bar") },
+        }
+    `)
+	foo := write(t, filepath.Join(dir, "src", "components", "foo", "root.tsx"), `export default 1`)
+	bar := write(t, filepath.Join(dir, "src", "bar.tsx"), `export default 2`)
+
+	entries, err := RootsTsProvider{File: "src/roots.ts"}.Discover(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"Foo": foo, "Bar": bar}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for _, e := range entries {
+		if e.Path != want[e.Name] {
+			t.Fatalf("entry %s resolved to %s, want %s", e.Name, e.Path, want[e.Name])
+		}
+	}
+}
+
+func TestRootsTsProvider_StylesAndRegex(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "roots.ts"), `
+        export const roots = {
+          Foo: lazyRoot(() => import(/* webpackChunkName: "foo-chunk" */ "./foo")),
+          Bar: routes.lazy(() => import("./bar")),
+        }
+        register("Baz", "./baz")
+    `)
+	foo := write(t, filepath.Join(dir, "foo.tsx"), `export default 1`)
+	bar := write(t, filepath.Join(dir, "bar.tsx"), `export default 2`)
+	baz := write(t, filepath.Join(dir, "baz.ts"), `export default 3`)
+
+	for _, tc := range []struct {
+		name string
+		prov RootsTsProvider
+		want map[string]string
+	}{
+		{"default style finds nothing", RootsTsProvider{File: "roots.ts"}, map[string]string{}},
+		{"call style", RootsTsProvider{File: "roots.ts", Style: "call"}, map[string]string{"Foo": foo, "Bar": bar}},
+		{"call style by chunk name", RootsTsProvider{File: "roots.ts", Style: "call", NameFrom: "webpackChunkName"}, map[string]string{"foo-chunk": foo, "Bar": bar}},
+		{"custom regex", RootsTsProvider{File: "roots.ts", Regex: `register\("(\w+)",\s*()"([^"]+)"\)`}, map[string]string{"Baz": baz}},
+	} {
+		entries, err := tc.prov.Discover(context.Background(), dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		got := map[string]string{}
+		for _, e := range entries {
+			got[e.Name] = e.Path
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	for _, prov := range []RootsTsProvider{
+		{File: "roots.ts", Style: "nope"},
+		{File: "roots.ts", Regex: `(\w+): "([^"]+)"`},
+	} {
+		if _, err := prov.Discover(context.Background(), dir); err == nil {
+			t.Fatalf("expected an error for %+v", prov)
+		}
+	}
+}
+
+func TestCheckEntry_StaleRoots(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "components", "live", "root.tsx"), "export default 1")
+	write(t, filepath.Join(dir, "components", "empty", "README.md"), "moved")
+	write(t, filepath.Join(dir, "roots.ts"), `export default {
+  Live: { moduleFactory: () => import("./components/live/root") },
+  Gone: { moduleFactory: () => import("./components/gone/root") },
+  Empty: { moduleFactory: () => import("./components/empty") },
+};`)
+
+	entries, err := RootsTsProvider{File: "roots.ts"}.Discover(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]bool{}
+	for _, e := range entries {
+		got[e.Name] = CheckEntry(dir, e) == nil
+	}
+	want := map[string]bool{"Live": true, "Gone": false, "Empty": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if err := CheckEntry(dir, scan.Entry{Path: "components/empty/README.md"}); err == nil {
+		t.Fatal("expected an error for a non-source entry")
+	}
+}
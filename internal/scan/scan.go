@@ -2,7 +2,9 @@ package scan
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/philjestin/philtographer/internal/graph"
 )
@@ -22,6 +25,8 @@ var (
 	reRequire    = regexp.MustCompile(`(?m)require\(\s*['"]([^'"]+)['"]\s*\)`)
 	reDynamic    = regexp.MustCompile(`(?m)import\(\s*['"]([^'"]+)['"]\s*\)`)
 	reExportFrom = regexp.MustCompile(`(?m)^\s*export\s+.*?\sfrom\s+['"]([^'"]+)['"]`)
+	// require('./handlers/' + name): only the static prefix is captured
+	reConcat = regexp.MustCompile(`(?m)(?:require|import)\(\s*['"]([^'"]+)['"]\s*\+`)
 )
 
 func isSource(path string) bool {
@@ -31,14 +36,65 @@ func isSource(path string) bool {
 	case ".ts", ".tsx", ".js", ".jsx":
 		return true
 	default:
-		return false
+		_, ok := preprocessorFor(path)
+		return ok
 	}
 }
 
 type Result struct {
-	File    string
-	Imports []string
-	Err     error
+	File     string
+	Imports  []string
+	Bytes    int              // size of the file contents
+	Lines    int              // number of lines in the file
+	Skip     string           // non-empty when the file was deliberately not parsed (the reason)
+	Computed []ComputedImport // import()/require() calls whose target is computed at runtime
+	Err      error
+}
+
+// countLines returns the number of lines in data, counting a final unterminated line.
+func countLines(data []byte) int {
+	n := bytes.Count(data, []byte{'\n'})
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// readFile is os.ReadFile, swappable in tests.
+var readFile = os.ReadFile
+
+// readRetries is how many times readSource retries a missing file, doubling the
+// wait from 10ms each time.
+const readRetries = 3
+
+// readSource reads a source file, retrying a few times while it doesn't exist:
+// editors that save atomically (write a temp file, rename it over) briefly leave
+// the path missing, and a scan racing the save would otherwise drop the file.
+// Other errors (permissions, a directory) won't go away and are returned at once.
+func readSource(path string) ([]byte, error) {
+	wait := 10 * time.Millisecond
+	data, err := readFile(path)
+	for i := 0; errors.Is(err, os.ErrNotExist) && i < readRetries; i++ {
+		time.Sleep(wait)
+		wait *= 2
+		data, err = readFile(path)
+	}
+	return data, err
+}
+
+// utf8BOM is the byte order mark some Windows editors put at the start of UTF-8 files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// NormalizeSource strips a leading UTF-8 BOM and turns CRLF (and lone CR) line
+// endings into LF, so line-anchored patterns and parsers see the same text for
+// files authored on Windows. Content without either is returned as is.
+func NormalizeSource(data []byte) []byte {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if bytes.IndexByte(data, '\r') < 0 {
+		return data
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
 }
 
 type Unresolved struct {
@@ -54,7 +110,11 @@ func isRelativeImport(spec string) bool {
 // Extracts import specifiers from file contents.
 // content is a string that contains code
 // it returns a slice of unique module names that were imported or required
+// Stylesheets are dropped, except CSS modules (see isCSSModule).
+// A relative path concatenated with a runtime value becomes a glob spec (see
+// IsApproximate).
 func ParseImports(content string) []string {
+	content = string(NormalizeSource([]byte(content)))
 	seen := map[string]struct{}{}
 
 	// helper function where ms is a slice of regex submatches from FindAllStringSubmatch
@@ -82,11 +142,12 @@ func ParseImports(content string) []string {
 	// Normalize, ignore style/assets and globs
 	out := make([]string, 0, len(seen))
 	for module := range seen {
-		l := strings.ToLower(module)
+		// judge "./icon.svg?react" by its path; the suffix stays in the spec
+		path, _ := splitSpecSuffix(module)
+		l := strings.ToLower(path)
 		// drop common non-code assets and globbed imports from .d.ts
 		if strings.Contains(module, "*") ||
-			strings.HasSuffix(l, ".css") ||
-			strings.HasSuffix(l, ".scss") ||
+			(!isCSSModule(path) && (strings.HasSuffix(l, ".css") || strings.HasSuffix(l, ".scss"))) ||
 			strings.HasSuffix(l, ".less") ||
 			strings.HasSuffix(l, ".yml") ||
 			strings.HasSuffix(l, ".jpg") ||
@@ -100,9 +161,35 @@ func ParseImports(content string) []string {
 		}
 		out = append(out, module)
 	}
+	for _, match := range reConcat.FindAllStringSubmatch(content, -1) {
+		if spec := approximateSpec(strings.TrimSpace(match[1])); spec != "" {
+			if _, dup := seen[spec]; !dup {
+				seen[spec] = struct{}{}
+				out = append(out, spec)
+			}
+		}
+	}
 	return out
 }
 
+// isCSSModule reports whether spec names a CSS module ("./Button.module.css" or
+// .module.scss). Unlike global stylesheets these export class names components
+// use, so they are real dependencies.
+func isCSSModule(spec string) bool {
+	path, _ := splitSpecSuffix(spec)
+	l := strings.ToLower(path)
+	return strings.HasSuffix(l, ".module.css") || strings.HasSuffix(l, ".module.scss")
+}
+
+// edgeKind is the kind OnEdge reports for a non-approximate import: graph.EdgeCSSModule
+// for CSS modules, "" for plain imports.
+func edgeKind(cssModule bool) string {
+	if cssModule {
+		return graph.EdgeCSSModule
+	}
+	return ""
+}
+
 // Very simple implementation of module resolution. This 100% gets re-written
 // fromFile is the file that contains the import
 // spec is the import string from that file
@@ -114,6 +201,7 @@ func Resolve(fromFile, spec string) (string, error) {
 	if !(strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/")) {
 		return "pkg:" + spec, nil
 	}
+	spec, _ = splitSpecSuffix(spec)
 
 	// Build a candidate path.
 	// Find the directory of fromFile, join it with spec to get the target path and remove the relative path
@@ -169,14 +257,106 @@ func Resolve(fromFile, spec string) (string, error) {
 	return "", fmt.Errorf("could not resolve %q from %q; tried: %v", spec, fromFile, attempts)
 }
 
+// DefaultMaxFiles is the source file limit used when Config.MaxFiles is 0.
+const DefaultMaxFiles = 200_000
+
+// ErrTooManyFiles is returned (wrapped) by BuildGraphWithConfig when the walk finds
+// more source files than Config.MaxFiles allows.
+var ErrTooManyFiles = errors.New("too many source files")
+
+// maxFiles returns the walk's file limit, or 0 for none.
+func (c Config) maxFiles() int {
+	switch {
+	case c.MaxFiles < 0:
+		return 0
+	case c.MaxFiles == 0:
+		return DefaultMaxFiles
+	}
+	return c.MaxFiles
+}
+
 // Walks through a source tree, parses imports, and builds a directed dependency graph concurrently.
 // ctx lets us cancel the work early
 // root is the root directory of the project.
 // returns a pointer to graph.Graph containing dependency edges between files.
 func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
+	g, _, err := BuildGraphWithConfig(ctx, Config{Root: root})
+	return g, err
+}
+
+// newConfigResolver returns a Resolver for cfg that records directories with
+// several index files in m.AmbiguousIndexes.
+func newConfigResolver(cfg Config, m *Manifest) (*Resolver, error) {
+	r := NewResolver(cfg.Root)
+	r.Extensions = cfg.Extensions
+	r.Workspaces = FindWorkspaces(cfg.Root, cfg.workspacePatterns())
+	r.BaseDirs = cfg.BaseDirs
+	if path := cfg.ImportMap; path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cfg.Root, path)
+		}
+		im, err := LoadImportMap(cfg.Root, path)
+		if err != nil {
+			return nil, err
+		}
+		r.ImportMap = im
+	}
+	if cfg.PnP {
+		pnp, err := LoadPnP(cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		r.PnP = pnp
+	}
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	r.OnAmbiguousIndex = func(a AmbiguousIndex) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !seen[a.Dir] {
+			seen[a.Dir] = true
+			m.AmbiguousIndexes = append(m.AmbiguousIndexes, a)
+		}
+	}
+	return r, nil
+}
+
+// BuildGraphWithConfig is BuildGraph driven by a Config, also returning a Manifest
+// describing what the scan saw (files parsed, unresolved specs).
+func BuildGraphWithConfig(ctx context.Context, cfg Config) (*graph.Graph, *Manifest, error) {
+	root := cfg.Root
 	g := graph.New()
+	m := &Manifest{}
 	// Use tsconfig-aware resolver for aliases/baseUrl.
-	resolver := NewResolver(root)
+	resolver, err := newConfigResolver(cfg, m)
+	if err != nil {
+		return g, m, err
+	}
+	confine := newRootChecker(root)
+	var deps *depTracker // nil unless cfg.DuplicateDeps
+	if cfg.DuplicateDeps {
+		deps = newDepTracker()
+	}
+	var pkgs *depTracker // nil unless cfg.NodeModulesDepth > 0
+	if cfg.NodeModulesDepth > 0 {
+		pkgs = newDepTracker()
+	}
+	walkRoot, err := cfg.walkRoot()
+	if err != nil {
+		return g, m, err
+	}
+	var boundary *rootChecker // nil unless cfg.Only
+	if cfg.Only != "" {
+		c := newRootChecker(walkRoot)
+		boundary = &c
+	}
+	// found and walked let the consumer report progress against the walk.
+	var found atomic.Int64
+	var walked atomic.Bool
+	// limitErr is set by the producer before it closes fileChannel, so it is
+	// visible once resultChannel closes.
+	var limitErr error
+	maxFiles := cfg.maxFiles()
 	// Channel of file paths (producer-consumer pattern here)
 	fileChannel := make(chan string, 1024)
 	// A channel of results from worker go routines
@@ -184,24 +364,27 @@ func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
 
 	// Producer to walk files concurrently
 	go func() {
-		filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return nil
+		walkSources(ctx, walkRoot, cfg.FollowSymlinks, func(path string) error {
+			if n := found.Add(1); maxFiles > 0 && n > int64(maxFiles) {
+				limitErr = fmt.Errorf("%w: more than %d under %s; check the root or raise the limit", ErrTooManyFiles, maxFiles, root)
+				return limitErr
 			}
-
-			if d.IsDir() {
-				// skip junk
-				name := d.Name()
-				if strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist" || name == "build" {
-					return filepath.SkipDir
+			if reason := cfg.skipReason(path); reason != "" {
+				select {
+				case resultChannel <- Result{File: path, Skip: reason}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 				return nil
 			}
-			if isSource(path) {
-				fileChannel <- path
+			select {
+			case fileChannel <- path:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
 			return nil
 		})
+		walked.Store(true)
 		close(fileChannel)
 	}()
 
@@ -218,13 +401,18 @@ func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
 		go func() {
 			defer wg.Done()
 			for path := range fileChannel {
-				data, err := os.ReadFile(path)
+				data, err := readSource(path)
 				if err != nil {
 					resultChannel <- Result{File: path, Err: err}
 					continue
 				}
-				imports := ParseImports(string(data))
-				resultChannel <- Result{File: path, Imports: imports, Err: nil}
+				if reason := cfg.contentSkipReason(data); reason != "" {
+					resultChannel <- Result{File: path, Skip: reason}
+					continue
+				}
+				src := preprocess(path, data)
+				imports := ParseImports(string(src))
+				resultChannel <- Result{File: path, Imports: imports, Bytes: len(data), Lines: countLines(data), Computed: findComputedImports(path, src), Err: nil}
 			}
 		}()
 	}
@@ -236,12 +424,16 @@ func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
 	}()
 
 	unresolved := make([]Unresolved, 0, 64)
+	done := 0
 
 	// Consume results
 	for {
 		select {
 		case <-ctx.Done():
-			return g, ctx.Err()
+			m.Unresolved = unresolved
+			m.Resolution = resolver.Stats()
+			m.DuplicateDeps = deps.duplicates()
+			return g, m, ctx.Err()
 
 		case r, ok := <-resultChannel:
 			if !ok {
@@ -249,31 +441,69 @@ func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
 				// If there are unresolved relative imports, keep the partial graph
 				// and do not fail the scan. This supports code understanding with
 				// ambient/type-only declarations that reference non-existent files.
-				// Optionally, these could be surfaced as warnings by the caller.
-				return g, nil
+				// They are surfaced to the caller through the manifest.
+				// A cancelled or over-limit walk also ends here, so report that.
+				m.Unresolved = unresolved
+				m.Resolution = resolver.Stats()
+				m.DuplicateDeps = deps.duplicates()
+				if limitErr != nil {
+					return g, m, limitErr
+				}
+				addNodeModuleEdges(ctx, cfg, pkgs, nodeModuleEdgeAdder(cfg, g))
+				return g, m, ctx.Err()
+			}
+
+			done++
+			if cfg.Progress != nil {
+				// read walked first: once it's set, found is final
+				counted := walked.Load()
+				cfg.Progress(Progress{Done: done, Found: int(found.Load()), Counted: counted})
 			}
 
 			if r.Err != nil {
 				// read/parse error for this file—skip (or collect separately)
 				continue
 			}
+			if r.Skip != "" {
+				m.Skipped = append(m.Skipped, Skipped{File: r.File, Reason: r.Skip})
+				continue
+			}
 
-			g.Touch(r.File)
+			m.Files++
+			m.Computed = append(m.Computed, r.Computed...)
+			g.SetAttrs(r.File, graph.NodeAttrs{Bytes: r.Bytes, Lines: r.Lines})
 
 			for _, spec := range r.Imports {
+				if IsApproximate(spec) {
+					for _, to := range ExpandApproximate(r.File, spec) {
+						if cfg.OnEdge != nil {
+							g.Touch(to)
+							cfg.OnEdge(r.File, to, spec, graph.EdgeApproximate)
+							continue
+						}
+						addApproximateEdge(g, r.File, to, spec)
+					}
+					continue
+				}
+				cssModule := isCSSModule(spec)
+				if cssModule && !cfg.CSSModules {
+					continue
+				}
 				to, err := resolver.Resolve(r.File, spec)
 				if err != nil {
-					// Only treat as unresolved if it was a relative spec;
+					// Only treat as unresolved if it was a relative spec or a resolution loop;
 					// externals are now dropped/kept without error.
-					if isRelativeImport(spec) {
+					if isRelativeImport(spec) || errors.Is(err, ErrResolveLoop) {
 						unresolved = append(unresolved, Unresolved{File: r.File, Spec: spec, Err: err})
 					}
 					continue
 				}
-				if to == "" {
+				deps.record(r.File, to)
+				if to == "" || cfg.dropExternal(to) {
 					// dropped external (Option A)
 					continue
 				}
+				pkgs.record(r.File, to)
 
 				// If it’s relative, sanity-check the resolved path exists (defensive)
 				if isRelativeImport(spec) {
@@ -288,7 +518,29 @@ func BuildGraph(ctx context.Context, root string) (*graph.Graph, error) {
 					}
 				}
 
-				g.AddEdge(r.File, to)
+				if to == r.File {
+					m.SelfImport = append(m.SelfImport, SelfImport{File: r.File, Spec: spec})
+					continue
+				}
+				if cfg.ConfineRoot && !strings.HasPrefix(to, "pkg:") && confine.outside(to) {
+					m.OutOfRoot = append(m.OutOfRoot, OutOfRoot{File: r.File, Spec: spec, To: to})
+				}
+				if boundary != nil && !strings.HasPrefix(to, "pkg:") && boundary.outside(to) {
+					if a, _ := g.Attrs(to); !a.Boundary {
+						a.Boundary = true
+						g.SetAttrs(to, a)
+					}
+				}
+
+				if cfg.OnEdge != nil {
+					g.Touch(to)
+					cfg.OnEdge(r.File, to, spec, edgeKind(cssModule))
+					continue
+				}
+				g.AddEdgeSpec(r.File, to, spec)
+				if cssModule {
+					g.SetEdgeKind(r.File, to, graph.EdgeCSSModule)
+				}
 			}
 		}
 	}
@@ -316,9 +568,32 @@ func FirstLines(path string, n int) (string, error) {
 // This walks only the reachable dependency closure starting from the given entries,
 // which is better for MPAs (Rails + many React roots) and faster on large repos.
 func BuildGraphFromEntries(ctx context.Context, root string, entries []Entry) (*graph.Graph, error) {
+	g, _, err := BuildGraphFromEntriesWithConfig(ctx, Config{Root: root}, entries)
+	return g, err
+}
+
+// BuildGraphFromEntriesWithConfig is BuildGraphFromEntries driven by a Config, also
+// returning a Manifest describing what the traversal saw.
+func BuildGraphFromEntriesWithConfig(ctx context.Context, cfg Config, entries []Entry) (*graph.Graph, *Manifest, error) {
+	root := cfg.Root
 	g := graph.New()
+	m := &Manifest{}
+	// gmu guards g and m; workers add edges concurrently.
+	var gmu sync.Mutex
 	// Use tsconfig-aware resolver for aliases/baseUrl.
-	resolver := NewResolver(root)
+	resolver, err := newConfigResolver(cfg, m)
+	if err != nil {
+		return g, m, err
+	}
+	confine := newRootChecker(root)
+	var deps *depTracker // nil unless cfg.DuplicateDeps
+	if cfg.DuplicateDeps {
+		deps = newDepTracker()
+	}
+	var pkgs *depTracker // nil unless cfg.NodeModulesDepth > 0
+	if cfg.NodeModulesDepth > 0 {
+		pkgs = newDepTracker()
+	}
 
 	// queue carries files to visit; we close it automatically when "inflight" hits zero.
 	queue := make(chan string, 4096)
@@ -375,21 +650,92 @@ func BuildGraphFromEntries(ctx context.Context, root string, entries []Entry) (*
 					}
 
 					// Read file and parse imports. Errors are non-fatal: we just skip the file.
-					data, err := os.ReadFile(path)
-					if err == nil {
-						g.Touch(path)
-						for _, spec := range ParseImports(string(data)) {
-							to, rerr := resolver.Resolve(path, spec)
-							if rerr == nil {
-								// Record the edge no matter if it's internal or external (pkg:...).
-								g.AddEdge(path, to)
-
-								// Only enqueue reachable local files (skip pkg: externals)
-								if isRelativeImport(spec) {
-									if info, statErr := os.Stat(to); statErr == nil && !info.IsDir() {
-										enqueue(to)
+					// Files skipped by path aren't read at all.
+					var data []byte
+					var err error
+					reason := cfg.skipReason(path)
+					if reason == "" {
+						data, err = readSource(path)
+						if err == nil {
+							reason = cfg.contentSkipReason(data)
+						}
+					}
+					if reason != "" {
+						gmu.Lock()
+						m.Skipped = append(m.Skipped, Skipped{File: path, Reason: reason})
+						gmu.Unlock()
+					} else if err == nil {
+						src := preprocess(path, data)
+						computed := findComputedImports(path, src)
+						gmu.Lock()
+						m.Files++
+						m.Computed = append(m.Computed, computed...)
+						g.SetAttrs(path, graph.NodeAttrs{Bytes: len(data), Lines: countLines(data)})
+						gmu.Unlock()
+						for _, spec := range ParseImports(string(src)) {
+							if IsApproximate(spec) {
+								targets := ExpandApproximate(path, spec)
+								gmu.Lock()
+								for _, to := range targets {
+									if cfg.OnEdge != nil {
+										g.Touch(to)
+										cfg.OnEdge(path, to, spec, graph.EdgeApproximate)
+									} else {
+										addApproximateEdge(g, path, to, spec)
 									}
 								}
+								gmu.Unlock()
+								for _, to := range targets {
+									enqueue(to)
+								}
+								continue
+							}
+							cssModule := isCSSModule(spec)
+							if cssModule && !cfg.CSSModules {
+								continue
+							}
+							to, rerr := resolver.Resolve(path, spec)
+							if rerr != nil {
+								if isRelativeImport(spec) || errors.Is(rerr, ErrResolveLoop) {
+									gmu.Lock()
+									m.Unresolved = append(m.Unresolved, Unresolved{File: path, Spec: spec, Err: rerr})
+									gmu.Unlock()
+								}
+								continue
+							}
+							deps.record(path, to)
+							if cfg.dropExternal(to) {
+								continue
+							}
+							pkgs.record(path, to)
+							if to == path {
+								gmu.Lock()
+								m.SelfImport = append(m.SelfImport, SelfImport{File: path, Spec: spec})
+								gmu.Unlock()
+								continue
+							}
+							escapes := cfg.ConfineRoot && !strings.HasPrefix(to, "pkg:") && confine.outside(to)
+							// Record the edge no matter if it's internal or external (pkg:...).
+							gmu.Lock()
+							if escapes {
+								m.OutOfRoot = append(m.OutOfRoot, OutOfRoot{File: path, Spec: spec, To: to})
+							}
+							if cfg.OnEdge != nil {
+								g.Touch(to)
+								cfg.OnEdge(path, to, spec, edgeKind(cssModule))
+							} else {
+								g.AddEdgeSpec(path, to, spec)
+								if cssModule {
+									g.SetEdgeKind(path, to, graph.EdgeCSSModule)
+								}
+							}
+							gmu.Unlock()
+
+							// Only enqueue reachable local files (skip pkg: externals and stylesheets)
+							if isRelativeImport(spec) && !cssModule {
+								if info, statErr := os.Stat(to); statErr == nil && !info.IsDir() {
+									enqueue(to)
+								}
 							}
 						}
 					}
@@ -406,5 +752,8 @@ func BuildGraphFromEntries(ctx context.Context, root string, entries []Entry) (*
 
 	// Wait for all workers to finish or context cancellation.
 	wg.Wait()
-	return g, ctx.Err()
+	addNodeModuleEdges(ctx, cfg, pkgs, nodeModuleEdgeAdder(cfg, g))
+	m.Resolution = resolver.Stats()
+	m.DuplicateDeps = deps.duplicates()
+	return g, m, ctx.Err()
 }
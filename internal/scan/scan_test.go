@@ -1,11 +1,20 @@
 package scan
 
 import (
+	"archive/zip"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/philjestin/philtographer/internal/graph"
 )
 
 func TestParseImports_FiltersAssetsAndGlobs(t *testing.T) {
@@ -25,6 +34,29 @@ func TestParseImports_FiltersAssetsAndGlobs(t *testing.T) {
 	}
 }
 
+func TestParseImports_BOMAndCRLF(t *testing.T) {
+	src := "\uFEFF" + strings.Join([]string{
+		`import a from "./a"`,
+		`import { b } from "./b"`,
+		`export * from "./c"`,
+		`const d = require("./d")`,
+	}, "\r\n") + "\rimport e from \"./e\"\r\n"
+	got := ParseImports(src)
+	want := []string{"./a", "./b", "./c", "./d", "./e"}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			found = found || g == w
+		}
+		if !found {
+			t.Fatalf("missing %q in %v", w, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
 func TestResolve_JsAndJsxAndIndex(t *testing.T) {
 	dir := t.TempDir()
 	// Create structure:
@@ -101,3 +133,1523 @@ func TestBuildGraphFromEntries_TransitiveAndExternals(t *testing.T) {
 		}
 	}
 }
+
+func TestResolver_PackageJSONImports(t *testing.T) {
+	dir := t.TempDir()
+	utils := filepath.Join(dir, "src", "utils")
+	if err := os.MkdirAll(utils, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// "#*" matches too, but the longer "#utils/" prefix wins, as in Node.
+	pkg := `{"name":"app","imports":{"#*":"./lib/*","#utils/*":"./src/utils/*"}}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(utils, "format.ts")
+	shadowed := filepath.Join(dir, "lib", "utils", "format.ts")
+	if err := os.MkdirAll(filepath.Dir(shadowed), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{want, shadowed} {
+		if err := os.WriteFile(path, []byte("export const f = 1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		r := NewResolver(dir)
+		to, err := r.Resolve(filepath.Join(dir, "src", "main.ts"), "#utils/format")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if to != want {
+			t.Fatalf("expected %s, got %s", want, to)
+		}
+	}
+}
+
+func TestBuildGraph_SkipsMinifiedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.ts":        "import './vendor.min.js'; import './gen'",
+		"vendor.min.js": "import 'garbage'",
+		"gen.js":        "var a=1;" + strings.Repeat("x", 200),
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, MaxLineLength: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, n := range g.Nodes() {
+		if n == "pkg:garbage" {
+			t.Fatalf("minified file was parsed: %v", g.Nodes())
+		}
+	}
+	if len(m.Skipped) != 2 {
+		t.Fatalf("expected vendor.min.js and gen.js to be skipped, got %+v", m.Skipped)
+	}
+
+	// files skipped by name aren't read at all
+	var read []string
+	readFile = func(path string) ([]byte, error) {
+		read = append(read, filepath.Base(path))
+		return os.ReadFile(path)
+	}
+	defer func() { readFile = os.ReadFile }()
+	_, m, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir, MaxLineLength: 100}, []Entry{{Path: filepath.Join(dir, "app.ts")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Skipped) != 1 || filepath.Base(m.Skipped[0].File) != "vendor.min.js" || slices.Contains(read, "vendor.min.js") {
+		t.Fatalf("entries: expected vendor.min.js skipped unread, got skipped %+v, read %v", m.Skipped, read)
+	}
+}
+
+func TestBuildGraphFromEntries_IgnorePragma(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.ts")
+	gen := filepath.Join(dir, "generated.ts")
+	if err := os.WriteFile(a, []byte("import './generated'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gen, []byte("/* eslint-disable */\n// @philtographer-ignore\nimport 'huge-dep'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, m, err := BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Path: a}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(g.OutNeighbors(gen)) != 0 {
+		t.Fatalf("expected ignored file to have no edges, got %v", g.OutNeighbors(gen))
+	}
+	if len(m.Skipped) != 1 || m.Skipped[0].File != gen || m.Skipped[0].Reason != "ignore pragma" {
+		t.Fatalf("expected generated.ts skipped by pragma, got %+v", m.Skipped)
+	}
+}
+
+func TestBuildGraph_SkipsInvalidUTF8(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app.ts")
+	legacy := filepath.Join(dir, "legacy.ts")
+	if err := os.WriteFile(app, []byte("import './legacy'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Latin-1: 0xe9 is "é" there but not valid UTF-8 on its own.
+	if err := os.WriteFile(legacy, []byte("// caf\xe9\nimport 'phantom'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	full, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromEntries, em, err := BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Path: app}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string]struct {
+		g *graph.Graph
+		m *Manifest
+	}{"full walk": {full, m}, "entries": {fromEntries, em}} {
+		if out := got.g.OutNeighbors(legacy); len(out) != 0 {
+			t.Errorf("%s: expected no edges out of the Latin-1 file, got %v", name, out)
+		}
+		if len(got.m.Skipped) != 1 || got.m.Skipped[0].File != legacy || !strings.HasPrefix(got.m.Skipped[0].Reason, ReasonNotUTF8) {
+			t.Errorf("%s: expected legacy.ts skipped as not UTF-8, got %+v", name, got.m.Skipped)
+		}
+	}
+	if want := "not valid UTF-8 (bad byte 0xe9 at offset 6)"; m.Skipped[0].Reason != want {
+		t.Errorf("reason = %q, want %q", m.Skipped[0].Reason, want)
+	}
+}
+
+func TestResolver_SymlinkLoops(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "pkg", "src", "a.ts")
+	if err := os.MkdirAll(filepath.Dir(from), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// loop -> loop2 -> loop
+	if err := os.Symlink(filepath.Join(dir, "loop2"), filepath.Join(dir, "pkg", "src", "loop")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "pkg", "src", "loop"), filepath.Join(dir, "loop2")); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(dir)
+	if _, err := r.Resolve(from, "./loop"); !errors.Is(err, ErrResolveLoop) {
+		t.Fatalf("expected ErrResolveLoop for a circular symlink, got %v", err)
+	}
+
+	// pkg/src/up points back at the root, so walking up from a file reached through
+	// it revisits the same real directories. That isn't a loop: the walk skips them
+	// and the spec still resolves.
+	if err := os.Symlink(dir, filepath.Join(dir, "pkg", "src", "up")); err != nil {
+		t.Fatal(err)
+	}
+	through := filepath.Join(dir, "pkg", "src", "up", "pkg", "src", "a.ts")
+	if to, err := r.Resolve(through, "@missing/alias"); err != nil || to != "pkg:@missing/alias" {
+		t.Fatalf("Resolve through a symlinked ancestor = %q, %v; want pkg:@missing/alias", to, err)
+	}
+}
+
+func TestBuildGraph_FollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	shared := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.ts"), []byte("import './linked/b'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shared, "b.ts"), []byte("export const b = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(shared, filepath.Join(dir, "linked")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	// A link pointing back up the tree must not cause infinite recursion.
+	if err := os.Symlink(dir, filepath.Join(shared, "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Files != 1 {
+		t.Fatalf("expected symlinked dir to be ignored by default, parsed %d files", m.Files)
+	}
+
+	g, m, err = BuildGraphWithConfig(context.Background(), Config{Root: dir, FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Files != 2 {
+		t.Fatalf("expected a.ts and linked/b.ts parsed once each, parsed %d files", m.Files)
+	}
+	b := filepath.Join(dir, "linked", "b.ts")
+	if out := g.OutNeighbors(filepath.Join(dir, "a.ts")); len(out) != 1 || out[0] != b {
+		t.Fatalf("expected a.ts -> %s, got %v", b, out)
+	}
+}
+
+func TestBuildGraph_ConfineRoot(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "repo")
+	sibling := filepath.Join(base, "sibling", "s.ts")
+	for _, p := range []string{filepath.Join(root, "x.ts"), sibling} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(sibling, []byte("export const s = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.ts"), []byte("import '../sibling/s'\nimport './x'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "x.ts"), []byte("export const x = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, m, err := BuildGraphWithConfig(context.Background(), Config{Root: root, ConfineRoot: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.OutOfRoot) != 1 || m.OutOfRoot[0].Spec != "../sibling/s" || m.OutOfRoot[0].To != sibling {
+		t.Fatalf("expected one out-of-root import of ../sibling/s, got %+v", m.OutOfRoot)
+	}
+}
+
+func TestResolver_WarmCachesConfigs(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "app", "src", "a.ts")
+	if err := os.MkdirAll(filepath.Dir(from), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tsconfig := filepath.Join(dir, "app", "tsconfig.json")
+	if err := os.WriteFile(tsconfig, []byte(`{"compilerOptions": {"paths": {"@lib/*": ["lib/*"]}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib := filepath.Join(dir, "app", "lib", "x.ts")
+	if err := os.MkdirAll(filepath.Dir(lib), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lib, []byte("export const x = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewResolver(dir)
+	r.Warm([]string{from})
+	// Later resolutions come from the cache, even if the config on disk goes away.
+	if err := os.Remove(tsconfig); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Resolve(from, "@lib/x"); err != nil || got != lib {
+		t.Fatalf("Resolve(@lib/x) = %q, %v; want %q from the warmed cache", got, err, lib)
+	}
+}
+
+func TestImpactedThroughBarrels(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"button.tsx":     "export function Button() {}",
+		"card.tsx":       "export const Card = 1",
+		"index.ts":       "// barrel\nexport * from './button'\nexport { Card } from './card'\n",
+		"usesButton.tsx": "import { Button } from './index'",
+		"usesCard.tsx":   "import { Card as C } from './index'",
+		"usesAll.tsx":    "import * as UI from './index'",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	got := ImpactedThroughBarrels(g, p("button.tsx"))
+	want := []string{p("usesAll.tsx"), p("usesButton.tsx")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("ImpactedThroughBarrels(button) = %v, want %v", got, want)
+	}
+	if plain := g.Impacted(p("button.tsx")); len(plain) != 4 {
+		t.Fatalf("expected plain Impacted to include the barrel and all importers, got %v", plain)
+	}
+}
+
+func TestBuildGraph_QuerySuffixes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"x.ts":    "export const x = 1",
+		"main.ts": "import raw from './x?raw'\nimport Icon from './icon.svg?react'\nimport w from './x#hash'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := ParseImports(files["main.ts"]); len(got) != 2 {
+		t.Fatalf("expected ./icon.svg?react to be filtered as an asset, got %v", got)
+	}
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Unresolved) != 0 {
+		t.Fatalf("expected no unresolved imports, got %+v", m.Unresolved)
+	}
+	main, x := filepath.Join(dir, "main.ts"), filepath.Join(dir, "x.ts")
+	if got := g.Specs(main, x); strings.Join(got, ",") != "./x#hash,./x?raw" {
+		t.Fatalf("expected the suffixed specs on main -> x, got %v", got)
+	}
+}
+
+func TestResolver_AmbiguousIndex(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"index.ts", "index.tsx", "main.ts"} {
+		p := filepath.Join(dir, "button", name)
+		if name == "main.ts" {
+			p = filepath.Join(dir, name)
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("import B from './button'\nexport default 1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main, ts, tsx := filepath.Join(dir, "main.ts"), filepath.Join(dir, "button", "index.ts"), filepath.Join(dir, "button", "index.tsx")
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !g.Has(ts) || len(g.Specs(main, ts)) == 0 {
+		t.Fatalf("expected the default order to pick index.ts, got edges %v", g.OutNeighbors(main))
+	}
+	if len(m.AmbiguousIndexes) != 1 || m.AmbiguousIndexes[0].Chosen != ts || m.AmbiguousIndexes[0].Others[0] != tsx {
+		t.Fatalf("expected one ambiguous index for button/, got %+v", m.AmbiguousIndexes)
+	}
+
+	g, _, err = BuildGraphWithConfig(context.Background(), Config{Root: dir, Extensions: []string{".tsx", ".ts"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors(main); len(got) != 1 || got[0] != tsx {
+		t.Fatalf("expected extensions order to pick index.tsx, got %v", got)
+	}
+}
+
+func TestBuildGraph_Progress(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.ts", "b.ts", "c.min.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("export {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var last Progress
+	calls := 0
+	_, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, Progress: func(p Progress) {
+		calls++
+		if p.Done < last.Done || p.Found < p.Done {
+			t.Errorf("inconsistent progress %+v after %+v", p, last)
+		}
+		last = p
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// skipped files count as processed too
+	if calls != 3 || last.Done != 3 || last.Found != 3 {
+		t.Fatalf("expected 3 calls ending at 3/3, got %d calls, last %+v", calls, last)
+	}
+}
+
+func TestBuildGraph_ExcludeExternals(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.ts")
+	if err := os.WriteFile(main, []byte("import React from 'react'\nimport { x } from './x'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "x.ts"), []byte("export const x = 1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, build := range map[string]func(Config) (*graph.Graph, error){
+		"scan": func(cfg Config) (*graph.Graph, error) {
+			g, _, err := BuildGraphWithConfig(context.Background(), cfg)
+			return g, err
+		},
+		"entries": func(cfg Config) (*graph.Graph, error) {
+			g, _, err := BuildGraphFromEntriesWithConfig(context.Background(), cfg, []Entry{{Name: "main", Path: main}})
+			return g, err
+		},
+	} {
+		g, err := build(Config{Root: dir, ExcludeExternals: true})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if g.Has("pkg:react") {
+			t.Fatalf("%s: expected no pkg: nodes, got %v", name, g.Nodes())
+		}
+		if got := g.OutNeighbors(main); len(got) != 1 || got[0] != filepath.Join(dir, "x.ts") {
+			t.Fatalf("%s: expected only the internal edge, got %v", name, got)
+		}
+	}
+}
+
+func TestBuildGraph_ExternalsAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.ts")
+	src := "import React from 'react'\nimport 'react/jsx-runtime'\nimport { Button } from '@acme/ui/button'\nimport _ from 'lodash'"
+	if err := os.WriteFile(main, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{
+		Root:               dir,
+		ExcludeExternals:   true,
+		ExternalsAllowlist: []string{"react", "@acme/*"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"pkg:@acme/ui/button", "pkg:react", "pkg:react/jsx-runtime"}
+	if got := g.OutNeighbors(main); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestResolver_WorkspaceSubpath(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"packages/ui/package.json":       `{"name": "@acme/ui"}`,
+		"packages/ui/src/index.ts":       "export * from './button'",
+		"packages/ui/src/button.tsx":     "export const Button = () => null",
+		"packages/ui/src/forms/input.ts": "export const Input = 1",
+		"apps/web/main.ts":               "import { Button } from '@acme/ui/button'",
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewResolver(dir)
+	r.Workspaces = FindWorkspaces(dir, []string{"packages/*"})
+	from := filepath.Join(dir, "apps/web/main.ts")
+	for spec, want := range map[string]string{
+		"@acme/ui":             "packages/ui/src/index.ts",
+		"@acme/ui/button":      "packages/ui/src/button.tsx",
+		"@acme/ui/forms/input": "packages/ui/src/forms/input.ts",
+		"@acme/ui/missing":     "",
+		"@acme/other":          "",
+	} {
+		got, err := r.Resolve(from, spec)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", spec, err)
+		}
+		if want == "" {
+			want = "pkg:" + spec
+		} else {
+			want = filepath.Join(dir, want)
+		}
+		if got != want {
+			t.Fatalf("Resolve(%q) = %q, want %q", spec, got, want)
+		}
+	}
+	if got := r.Stats().Workspace; got != 3 {
+		t.Fatalf("expected 3 workspace resolutions, got %d", got)
+	}
+}
+
+func TestBuildGraph_Preprocessors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.ts": "import App from './App.vue'",
+		"App.vue": `<template><Card /></template>
+<script setup lang="ts">
+import Card from './Card.svelte'
+</script>
+<style>@import './theme.css';</style>`,
+		"Card.svelte": `<script context="module">import { load } from './load'</script>
+<script>import Doc from './doc.demo'</script>
+<p>import notAnImport from './markup'</p>`,
+		"load.ts":  "export const load = 1",
+		"doc.demo": "@use ./load\nimport fake from './ignored'",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a custom format: "@use <spec>" lines become imports
+	RegisterPreprocessor(".demo", func(src []byte) []byte {
+		var out []string
+		for _, line := range strings.Split(string(src), "\n") {
+			if spec, ok := strings.CutPrefix(line, "@use "); ok {
+				out = append(out, "import '"+spec+"'")
+			}
+		}
+		return []byte(strings.Join(out, "\n"))
+	})
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+	for from, want := range map[string][]string{
+		"App.vue":     {p("Card.svelte")},
+		"Card.svelte": {p("doc.demo"), p("load.ts")},
+		"doc.demo":    {p("load.ts")},
+	} {
+		if got := g.OutNeighbors(p(from)); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: expected %v, got %v", from, want, got)
+		}
+	}
+}
+
+func TestBuildGraph_DuplicateDeps(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"node_modules/react/package.json":                 `{"name": "react", "version": "18.2.0"}`,
+		"node_modules/clsx/package.json":                  `{"name": "clsx", "version": "2.0.0"}`,
+		"packages/legacy/node_modules/react/package.json": `{"name": "react", "version": "17.0.2"}`,
+		"packages/app/main.tsx":                           "import React from 'react'\nimport clsx from 'clsx'",
+		"packages/app/other.tsx":                          "import { jsx } from 'react/jsx-runtime'",
+		"packages/legacy/old.tsx":                         "import React from 'react'\nimport clsx from 'clsx'",
+		"packages/legacy/missing.ts":                      "import x from 'not-installed'",
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, DuplicateDeps: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []DuplicateDep{{Name: "react", Copies: []PackageCopy{
+		{Dir: filepath.Join(dir, "node_modules/react"), Version: "18.2.0", Importers: 2},
+		{Dir: filepath.Join(dir, "packages/legacy/node_modules/react"), Version: "17.0.2", Importers: 1},
+	}}}
+	if !reflect.DeepEqual(m.DuplicateDeps, want) {
+		t.Fatalf("DuplicateDeps = %+v, want %+v", m.DuplicateDeps, want)
+	}
+}
+
+func TestResolver_CommentedTSConfig(t *testing.T) {
+	dir := t.TempDir()
+	tsconfig := `{
+  // path aliases for the app
+  "compilerOptions": {
+    "baseUrl": ".", /* relative to this file */
+    "paths": {
+      "@app/*": ["src/*",], // trailing comma
+      "@url/*": ["src/http://not-a-comment/*"],
+    },
+  },
+}`
+	if err := os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte(tsconfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "src", "button.ts")
+	if err := os.WriteFile(want, []byte("export {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(dir)
+	if to, err := r.Resolve(filepath.Join(dir, "main.ts"), "@app/button"); err != nil || to != want {
+		t.Fatalf("Resolve(@app/button) = %q, %v; want %q", to, err, want)
+	}
+	// the nearest-tsconfig walk parses the same file
+	sub := filepath.Join(dir, "src", "deep", "x.ts")
+	if to, ok, err := r.resolveWithNearest(sub, "@app/button"); err != nil || !ok || to != want {
+		t.Fatalf("resolveWithNearest(@app/button) = %q, %v, %v; want %q", to, ok, err, want)
+	}
+	if got := string(stripJSONC([]byte(`{"a": "x // y", "b": [1,]}`))); got != `{"a": "x // y", "b": [1 ]}` {
+		t.Fatalf("stripJSONC kept/changed the wrong bytes: %s", got)
+	}
+}
+
+func TestResolver_Stats(t *testing.T) {
+	dir := t.TempDir()
+	tsconfig := `{"compilerOptions": {"baseUrl": ".", "paths": {"@app/*": ["src/*"]}}}`
+	files := map[string]string{
+		"tsconfig.json":  tsconfig,
+		"src/button.ts":  "export {}",
+		"src/lib/fmt.ts": "export {}",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewResolver(dir)
+	from := filepath.Join(dir, "src", "main.ts")
+	for _, spec := range []string{"./button", "./missing", "@app/button", "@app/gone", "src/lib/fmt", "react"} {
+		r.Resolve(from, spec)
+	}
+	want := ResolveStats{Relative: 1, RelativeFailed: 1, Alias: 1, Bare: 2, AliasMissed: 1}
+	got := r.Stats()
+	// baseUrl hits may come from the root config or the nearest-config walk, which
+	// finds the same tsconfig here
+	if got.BaseURL+got.Nearest != 1 {
+		t.Fatalf("expected src/lib/fmt to resolve via baseUrl, got %+v", got)
+	}
+	got.BaseURL, got.Nearest = 0, 0
+	if got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+	if c := got.Coverage(); c != 0.5 {
+		t.Fatalf("Coverage() = %v, want 0.5 (one of two relative specs resolved)", c)
+	}
+	if c := (ResolveStats{Bare: 3}).Coverage(); c != 1 {
+		t.Fatalf("Coverage() with no relative specs = %v, want 1", c)
+	}
+}
+
+func TestBuildGraph_CancelledContextStopsWalk(t *testing.T) {
+	dir := t.TempDir()
+	for i := 1; i <= 50; i++ {
+		if err := os.WriteFile(filepath.Join(dir, strings.Repeat("f", i)+".ts"), []byte("export const x = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, m, err := BuildGraphWithConfig(ctx, Config{Root: dir})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if m.Files != 0 {
+		t.Fatalf("walked %d files after cancellation", m.Files)
+	}
+}
+
+func TestBuildGraph_MaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	for i := 1; i <= 5; i++ {
+		if err := os.WriteFile(filepath.Join(dir, strings.Repeat("f", i)+".ts"), []byte("export const x = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, MaxFiles: 3}); !errors.Is(err, ErrTooManyFiles) {
+		t.Fatalf("err = %v, want ErrTooManyFiles", err)
+	}
+	if _, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, MaxFiles: 5}); err != nil {
+		t.Fatalf("at the limit: %v", err)
+	}
+	if _, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, MaxFiles: -1}); err != nil {
+		t.Fatalf("unlimited: %v", err)
+	}
+}
+
+func TestBuildGraph_CSSModules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Button.tsx":        "import styles from './Button.module.css'\nimport './global.css'\nimport theme from './theme.module.scss'",
+		"Button.module.css": ".root { color: red }",
+		"theme.module.scss": "$c: red;",
+		"global.css":        "body { margin: 0 }",
+		"entry.ts":          "import Button from './Button'",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+	want := []string{p("Button.module.css"), p("theme.module.scss")}
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors(p("Button.tsx")); len(got) != 0 {
+		t.Fatalf("expected no edges without CSSModules, got %v", got)
+	}
+
+	g, _, err = BuildGraphWithConfig(context.Background(), Config{Root: dir, CSSModules: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors(p("Button.tsx")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("scan: expected %v, got %v", want, got)
+	}
+	for _, to := range want {
+		if k := g.EdgeKind(p("Button.tsx"), to); k != graph.EdgeCSSModule {
+			t.Fatalf("edge to %s: kind %q, want %q", to, k, graph.EdgeCSSModule)
+		}
+	}
+
+	g, _, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir, CSSModules: true}, []Entry{{Name: "entry", Path: p("entry.ts")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors(p("Button.tsx")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries: expected %v, got %v", want, got)
+	}
+}
+
+func TestBuildGraph_Only(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"payments/checkout.ts": "import { fmt } from '../shared/money'\nimport { charge } from './charge'\nimport React from 'react'",
+		"payments/charge.ts":   "export const charge = 1",
+		"shared/money.ts":      "import { round } from './math'\nexport const fmt = 1",
+		"shared/math.ts":       "export const round = 1",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, Only: "payments"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Files != 2 {
+		t.Fatalf("expected 2 files walked, got %d", m.Files)
+	}
+	want := []string{p("payments/charge.ts"), p("shared/money.ts"), "pkg:react"}
+	if got := g.OutNeighbors(p("payments/checkout.ts")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if a, _ := g.Attrs(p("shared/money.ts")); !a.Boundary {
+		t.Fatalf("shared/money.ts not marked boundary: %+v", a)
+	}
+	if a, _ := g.Attrs(p("payments/charge.ts")); a.Boundary {
+		t.Fatalf("payments/charge.ts marked boundary")
+	}
+	if g.Has(p("shared/math.ts")) {
+		t.Fatalf("boundary file's imports were followed")
+	}
+
+	if _, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, Only: "missing"}); err == nil {
+		t.Fatalf("expected an error for a missing --only directory")
+	}
+}
+
+func TestResolver_BaseDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/components/Button.tsx", "shared/components/Button.tsx", "shared/hooks/useToggle.ts", "vendor/js/hooks/useToggle.js", "vendor/js/utils/date.js", "src/app.tsx"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("export {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte(`{"compilerOptions": {"baseUrl": "src"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(dir)
+	r.BaseDirs = []string{"shared", filepath.Join(dir, "vendor/js")}
+	p := func(name string) string { return filepath.Join(dir, name) }
+	for _, tc := range []struct{ spec, want string }{
+		{"components/Button", p("src/components/Button.tsx")}, // baseUrl first
+		{"hooks/useToggle", p("shared/hooks/useToggle.ts")},   // then baseDirs in order
+		{"utils/date", p("vendor/js/utils/date.js")},          // absent from the first base dir
+		{"react", "pkg:react"},
+	} {
+		got, err := r.Resolve(p("src/app.tsx"), tc.spec)
+		if err != nil || got != tc.want {
+			t.Fatalf("Resolve(%q) = %q, %v; want %q", tc.spec, got, err, tc.want)
+		}
+	}
+}
+
+// A team that imports "components/Foo" from src/ without a tsconfig baseUrl
+// lists src in baseDirs.
+func TestResolver_BaseDirsWithoutTsconfig(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/components/Foo.tsx", "src/pages/home.tsx"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("export {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewResolver(dir)
+	r.BaseDirs = []string{"src"}
+	from := filepath.Join(dir, "src/pages/home.tsx")
+	if got, err := r.Resolve(from, "components/Foo"); err != nil || got != filepath.Join(dir, "src/components/Foo.tsx") {
+		t.Fatalf("Resolve(components/Foo) = %q, %v; want src/components/Foo.tsx", got, err)
+	}
+	if got, _ := r.Resolve(from, "react"); got != "pkg:react" {
+		t.Fatalf("Resolve(react) = %q, want pkg:react", got)
+	}
+	if s := r.Stats(); s.BaseURL != 1 || s.Bare != 1 {
+		t.Fatalf("stats = %+v, want one baseUrl and one bare", s)
+	}
+}
+
+func TestResolver_ImportMap(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"vendor/lit/index.js", "vendor/lit/directives/repeat.js", "legacy/lit.js", "src/app.js", "legacy/old.js"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("export {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	importMap := `{
+  "imports": {
+    "lit": "/vendor/lit/index.js",
+    "lit/": "/vendor/lit/",
+    "three": "https://cdn.example.com/three.js"
+  },
+  "scopes": {
+    "/legacy/": { "lit": "./legacy/lit.js" }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(dir, "importmap.json"), []byte(importMap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	im, err := LoadImportMap(dir, filepath.Join(dir, "importmap.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(dir)
+	r.ImportMap = im
+	p := func(name string) string { return filepath.Join(dir, name) }
+	for _, tc := range []struct{ from, spec, want string }{
+		{"src/app.js", "lit", p("vendor/lit/index.js")},
+		{"src/app.js", "lit/directives/repeat", p("vendor/lit/directives/repeat.js")},
+		{"src/app.js", "three", "pkg:three"},
+		{"legacy/old.js", "lit", p("legacy/lit.js")},
+		{"legacy/old.js", "lit/directives/repeat.js", p("vendor/lit/directives/repeat.js")},
+	} {
+		got, err := r.Resolve(p(tc.from), tc.spec)
+		if err != nil || got != tc.want {
+			t.Fatalf("Resolve(%s, %q) = %q, %v; want %q", tc.from, tc.spec, got, err, tc.want)
+		}
+	}
+	if got := r.Stats().ImportMap; got != 4 {
+		t.Fatalf("ImportMap count = %d, want 4", got)
+	}
+
+	// Config.ImportMap may be absolute as well as relative to Root.
+	if err := os.WriteFile(p("src/app.js"), []byte("import 'lit'"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"importmap.json", p("importmap.json")} {
+		g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, ImportMap: path})
+		if err != nil {
+			t.Fatalf("ImportMap %s: %v", path, err)
+		}
+		if got, want := g.OutNeighbors(p("src/app.js")), []string{p("vendor/lit/index.js")}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("ImportMap %s: app.js imports %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestBuildGraph_RetriesTransientReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.ts": "import { b } from './b'",
+		"b.ts": "import { c } from './c'",
+		"c.ts": "export const c = 1",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b.ts is mid-save (renamed away) on the first read, for both builders
+	var failed atomic.Int32
+	readFile = func(path string) ([]byte, error) {
+		if filepath.Base(path) == "b.ts" && failed.Add(1)%2 == 1 {
+			return nil, os.ErrNotExist
+		}
+		return os.ReadFile(path)
+	}
+	defer func() { readFile = os.ReadFile }()
+
+	p := func(name string) string { return filepath.Join(dir, name) }
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Files != 3 || !reflect.DeepEqual(g.OutNeighbors(p("b.ts")), []string{p("c.ts")}) {
+		t.Fatalf("scan dropped b.ts: files=%d, b.ts -> %v", m.Files, g.OutNeighbors(p("b.ts")))
+	}
+	g, _, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Path: p("a.ts")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(g.OutNeighbors(p("b.ts")), []string{p("c.ts")}) {
+		t.Fatalf("entries dropped b.ts: b.ts -> %v", g.OutNeighbors(p("b.ts")))
+	}
+
+	// errors other than a missing file are permanent and not retried
+	var reads int
+	readFile = func(path string) ([]byte, error) {
+		reads++
+		return nil, os.ErrPermission
+	}
+	if _, err := readSource(p("a.ts")); !errors.Is(err, os.ErrPermission) || reads != 1 {
+		t.Fatalf("readSource = %v after %d reads, want ErrPermission after 1", err, reads)
+	}
+}
+
+func TestConfig_LayerOf(t *testing.T) {
+	cfg := Config{Root: "/repo", Layers: []LayerRule{
+		{Pattern: "src/ui/legacy/**", Layer: "legacy"},
+		{Pattern: "src/ui/**", Layer: "ui"},
+		{Pattern: "src/domain/**", Layer: "domain"},
+	}}
+	for path, want := range map[string]string{
+		"/repo/src/ui/button.tsx":        "ui",
+		"/repo/src/ui/legacy/modal.tsx":  "legacy",
+		"/repo/src/domain/cart/model.ts": "domain",
+		"/repo/scripts/seed.ts":          "",
+		"pkg:react":                      "",
+	} {
+		if got := cfg.LayerOf(path); got != want {
+			t.Errorf("LayerOf(%s) = %q, want %q", path, got, want)
+		}
+	}
+	if got, want := cfg.LayerNames(), []string{"legacy", "ui", "domain"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("LayerNames() = %v, want %v", got, want)
+	}
+}
+
+func TestFileImports(t *testing.T) {
+	src := strings.Join([]string{
+		`import a from "./a"`,
+		`import type { B } from "../b"`,
+		`export * from "./c"`,
+		`const d = require("./d")`,
+		`const e = await import("./e")`,
+		`import "./styles.css"`,
+	}, "\n")
+	got := FileImports("x.ts", []byte(src))
+	sort.Strings(got)
+	want := []string{"../b", "./a", "./c", "./d", "./e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FileImports() = %v, want %v", got, want)
+	}
+
+	// JSX in a .ts file: the TypeScript grammar fails, the TSX retry doesn't.
+	src = "import { Button } from './button'\nexport const Bar = () => <div><Button /></div>\nconst Lazy = () => import('./lazy')\n"
+	if tree := ParseSource("bar.ts", []byte(src)); tree == nil || tree.RootNode().HasError() {
+		t.Fatal("expected a clean TSX parse for a .ts file containing JSX")
+	}
+	got = FileImports("bar.ts", []byte(src))
+	sort.Strings(got)
+	if want := []string{"./button", "./lazy"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FileImports() with JSX = %v, want %v", got, want)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/app.ts", "src/vendor.min.js", "src/readme.md", "node_modules/react/index.js", "dist/app.js", "lib/util.ts"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("export const x = 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	files, skipped, err := ListFiles(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{p("lib/util.ts"), p("src/app.ts")}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("files = %v, want %v", files, want)
+	}
+	if len(skipped) != 1 || skipped[0].File != p("src/vendor.min.js") {
+		t.Fatalf("skipped = %+v, want src/vendor.min.js", skipped)
+	}
+
+	files, _, err = ListFiles(context.Background(), Config{Root: dir, Only: "lib"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{p("lib/util.ts")}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("with Only: files = %v, want %v", files, want)
+	}
+}
+
+func TestBuildGraph_ConcatenatedRequire(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.js":              "const h = require('./handlers/' + name)\nconst on = require('./handlers/on' + event + '.js')\nconst lib = require('lodash/' + fn)",
+		"handlers/create.js":  "module.exports = 1",
+		"handlers/onClick.js": "module.exports = 1",
+		"handlers/README.md":  "docs",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	specs := ParseImports(files["app.js"])
+	sort.Strings(specs)
+	if want := []string{"./handlers/*", "./handlers/on*"}; !reflect.DeepEqual(specs, want) {
+		t.Fatalf("ParseImports() = %v, want %v", specs, want)
+	}
+	ast := FileImports("app.js", []byte(files["app.js"]))
+	sort.Strings(ast)
+	if !reflect.DeepEqual(ast, specs) {
+		t.Fatalf("FileImports() = %v, want %v", ast, specs)
+	}
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{p("handlers/create.js"), p("handlers/onClick.js")}
+	if got := g.OutNeighbors(p("app.js")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for _, to := range want {
+		if k := g.EdgeKind(p("app.js"), to); k != graph.EdgeApproximate {
+			t.Fatalf("edge to %s: kind %q, want %q", to, k, graph.EdgeApproximate)
+		}
+	}
+	if got, want := g.Specs(p("app.js"), p("handlers/onClick.js")), []string{"./handlers/*", "./handlers/on*"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("specs = %v, want %v", got, want)
+	}
+
+	g, _, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Name: "app", Path: p("app.js")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors(p("app.js")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries: expected %v, got %v", want, got)
+	}
+
+	// Streamed edges carry the kind too.
+	kinds := map[string]string{}
+	cfg := Config{Root: dir, OnEdge: func(from, to, spec, kind string) { kinds[to] = kind }}
+	if _, _, err := BuildGraphWithConfig(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range want {
+		if kinds[to] != graph.EdgeApproximate {
+			t.Fatalf("streamed edge to %s: kind %q, want %q", to, kinds[to], graph.EdgeApproximate)
+		}
+	}
+}
+
+func TestProbePackageFile(t *testing.T) {
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "node_modules", "lib")
+	for _, name := range []string{"node_modules/lib/..shared.js", "node_modules/outside.js"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("module.exports = 1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a name starting with ".." is still inside the package
+	if got, want := probePackageFile(pkg, filepath.Join(pkg, "..shared")), filepath.Join(pkg, "..shared.js"); got != want {
+		t.Fatalf("probePackageFile(..shared) = %q, want %q", got, want)
+	}
+	if got := probePackageFile(pkg, filepath.Join(pkg, "..", "outside.js")); got != "" {
+		t.Fatalf("probePackageFile(../outside.js) = %q, want it rejected", got)
+	}
+}
+
+func TestBuildGraph_NodeModulesDepth(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/app.tsx":                            "import { Dialog } from '@acme/ui'",
+		"node_modules/@acme/ui/package.json":     `{"name": "@acme/ui", "main": "lib/index.js"}`,
+		"node_modules/@acme/ui/lib/index.js":     "export * from './dialog'\nimport '@acme/ui/styles'",
+		"node_modules/@acme/ui/lib/dialog.js":    "const clsx = require('clsx')\nimport React from 'react'",
+		"node_modules/clsx/package.json":         `{"name": "clsx", "module": "dist/clsx.mjs"}`,
+		"node_modules/clsx/dist/clsx.mjs":        "export default function clsx() {}",
+		"node_modules/react/package.json":        `{"name": "react"}`,
+		"node_modules/react/index.js":            "module.exports = require('./cjs/react')",
+		"node_modules/react/cjs/react.js":        "require('scheduler')",
+		"node_modules/scheduler/package.json":    `{"name": "scheduler"}`,
+		"node_modules/scheduler/index.js":        "require('loose-envify')",
+		"node_modules/loose-envify/package.json": `{"name": "loose-envify"}`,
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.OutNeighbors("pkg:@acme/ui"); len(got) != 0 {
+		t.Fatalf("packages read without NodeModulesDepth: %v", got)
+	}
+
+	g, _, err = BuildGraphWithConfig(context.Background(), Config{Root: dir, NodeModulesDepth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.OutNeighbors("pkg:@acme/ui"), []string{"pkg:clsx", "pkg:react"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("depth 1: OutNeighbors(@acme/ui) = %v, want %v", got, want)
+	}
+	if got := g.OutNeighbors("pkg:react"); len(got) != 0 {
+		t.Fatalf("depth 1 followed react's imports: %v", got)
+	}
+
+	g, _, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir, NodeModulesDepth: 2}, []Entry{{Name: "app", Path: filepath.Join(dir, "src/app.tsx")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.OutNeighbors("pkg:react"), []string{"pkg:scheduler"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("depth 2: OutNeighbors(react) = %v, want %v", got, want)
+	}
+	if got := g.OutNeighbors("pkg:scheduler"); len(got) != 0 {
+		t.Fatalf("depth 2 followed scheduler's imports: %v", got)
+	}
+}
+
+func TestCheckImportStyle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"button.ts": "export default function Button() {}\nexport const size = 1",
+		"a.ts":      "import Button from './button'\nimport { size } from './button'\nimport type { Props } from './button'",
+		"b.ts":      "import { size } from './button'",
+		"c.ts":      "import * as B from './button'",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	got := ParseImportBindings(files["a.ts"])
+	want := []ImportBinding{
+		{Spec: "./button", Default: true},
+		{Spec: "./button", Named: []string{"size"}},
+		{Spec: "./button", Named: []string{"Props"}, TypeOnly: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseImportBindings() = %+v, want %+v", got, want)
+	}
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report := CheckImportStyle(g)
+	if want := []DuplicateImport{{File: p("a.ts"), Spec: "./button", Count: 2}}; !reflect.DeepEqual(report.Duplicates, want) {
+		t.Fatalf("Duplicates = %+v, want %+v", report.Duplicates, want)
+	}
+	if want := []MixedImport{{Module: p("button.ts"), Default: []string{p("a.ts")}, Named: []string{p("b.ts")}}}; !reflect.DeepEqual(report.Mixed, want) {
+		t.Fatalf("Mixed = %+v, want %+v", report.Mixed, want)
+	}
+}
+
+func TestBuildGraph_AutoWorkspaces(t *testing.T) {
+	write := func(dir string, files map[string]string) {
+		for name, src := range files {
+			p := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	pkgs := map[string]string{
+		"packages/ui/package.json":     `{"name": "@acme/ui"}`,
+		"packages/ui/src/index.ts":     "export const Button = 1",
+		"packages/secret/package.json": `{"name": "@acme/secret"}`,
+		"packages/secret/src/index.ts": "export const s = 1",
+		"apps/web/package.json":        `{"name": "web"}`,
+		"apps/web/main.ts":             "import { Button } from '@acme/ui'\nimport { s } from '@acme/secret'",
+	}
+
+	npm := t.TempDir()
+	write(npm, pkgs)
+	write(npm, map[string]string{"package.json": `{"workspaces": ["packages/*", "apps/*", "!packages/secret"]}`})
+	if got, want := DetectWorkspaces(npm), []string{"packages/*", "apps/*", "!packages/secret"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("DetectWorkspaces() = %v, want %v", got, want)
+	}
+	main := filepath.Join(npm, "apps/web/main.ts")
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: npm, AutoWorkspaces: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{filepath.Join(npm, "packages/ui/src/index.ts"), "pkg:@acme/secret"}
+	if got := g.OutNeighbors(main); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	g, _, err = BuildGraphWithConfig(context.Background(), Config{Root: npm})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.OutNeighbors(main), []string{"pkg:@acme/secret", "pkg:@acme/ui"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("without AutoWorkspaces: expected %v, got %v", want, got)
+	}
+
+	pnpm := t.TempDir()
+	write(pnpm, pkgs)
+	write(pnpm, map[string]string{"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n  - 'apps/*'\n"})
+	if got, want := DetectWorkspaces(pnpm), []string{"packages/*", "apps/*"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pnpm: DetectWorkspaces() = %v, want %v", got, want)
+	}
+
+	yarn := t.TempDir()
+	write(yarn, map[string]string{"package.json": `{"workspaces": {"packages": ["packages/*"], "nohoist": ["**/x"]}}`})
+	if got, want := DetectWorkspaces(yarn), []string{"packages/*"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("yarn: DetectWorkspaces() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildGraph_SelfImport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.ts": "import { x } from './a'\nimport { y } from './b'",
+		"b.ts": "export const y = 1",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(dir, "a.ts")
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []SelfImport{{File: a, Spec: "./a"}}; !reflect.DeepEqual(m.SelfImport, want) {
+		t.Fatalf("SelfImport = %v, want %v", m.SelfImport, want)
+	}
+	// Graph.AddEdge ignores self loops, as it always has.
+	if want := []string{filepath.Join(dir, "b.ts")}; !reflect.DeepEqual(g.OutNeighbors(a), want) {
+		t.Fatalf("expected only the b.ts edge, got %v", g.OutNeighbors(a))
+	}
+
+	// Streamed edges skip it too, so ndjson output matches the document.
+	var streamed []string
+	cfg := Config{Root: dir, OnEdge: func(from, to, spec, kind string) {
+		if from == a {
+			streamed = append(streamed, spec)
+		}
+	}}
+	if _, _, err := BuildGraphWithConfig(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(streamed)
+	if want := []string{"./b"}; !reflect.DeepEqual(streamed, want) {
+		t.Fatalf("streamed specs from a.ts = %v, want %v", streamed, want)
+	}
+
+	_, m, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Path: a}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.SelfImport) != 1 {
+		t.Fatalf("entries SelfImport = %v, want one", m.SelfImport)
+	}
+}
+
+func TestBuildGraph_PnP(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/app.ts": "import get from 'lodash/get'\nimport _ from 'lodash'\nimport pad from 'left-pad'\nimport missing from 'not-a-dep'",
+		".yarn/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/package.json": `{"main": "lib/pad"}`,
+		".yarn/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/lib/pad.js":   "module.exports = 1",
+		// The inlined state is a single-quoted JS string with line continuations.
+		".pnp.cjs": `#!/usr/bin/env node
+/* eslint-disable */
+const RAW_RUNTIME_STATE =
+'{\
+  "enableTopLevelFallback": false,\
+  "packageRegistryData": [\
+    [null, [[null, {"packageLocation": "./", "packageDependencies": [["lodash", "npm:4.17.21"], ["left-pad", ["left-pad", "virtual:abc#npm:1.3.0"]]]}]]],\
+    ["lodash", [["npm:4.17.21", {"packageLocation": "./.yarn/cache/lodash-npm-4.17.21.zip/node_modules/lodash/", "packageDependencies": []}]]],\
+    ["left-pad", [["virtual:abc#npm:1.3.0", {"packageLocation": "./.yarn/__virtual__/left-pad-virtual-abc/0/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/", "packageDependencies": []}]]]\
+  ]\
+}';
+
+function $$SETUP_STATE(hydrateRuntimeState, basePath) {}
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(dir, ".yarn/cache/lodash-npm-4.17.21.zip")
+	if err := os.MkdirAll(filepath.Dir(archive), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, src := range map[string]string{
+		"node_modules/lodash/package.json": `{"main": "lodash.js"}`,
+		"node_modules/lodash/lodash.js":    "module.exports = {}",
+		"node_modules/lodash/get.js":       "module.exports = {}",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(src))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	g, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir, PnP: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		filepath.Join(archive, "node_modules/lodash/get.js"),
+		filepath.Join(archive, "node_modules/lodash/lodash.js"),
+		filepath.Join(dir, ".yarn/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/lib/pad.js"),
+		"pkg:not-a-dep",
+	}
+	sort.Strings(want)
+	if got := g.OutNeighbors(filepath.Join(dir, "src/app.ts")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := m.Resolution.PnP; got != 3 {
+		t.Fatalf("Resolution.PnP = %d, want 3", got)
+	}
+
+	if _, _, err := BuildGraphWithConfig(context.Background(), Config{Root: t.TempDir(), PnP: true}); err == nil {
+		t.Fatal("expected an error without PnP data")
+	}
+}
+
+func TestBuildGraph_AliasedReexport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tsconfig.json":           `{"compilerOptions": {"baseUrl": ".", "paths": {"@app/*": ["src/*"]}}}`,
+		"src/components/index.ts": "export { Foo } from '@app/foo'\nexport * as Bar from '@app/bar'",
+		"src/foo.tsx":             "export function Foo() { return null }",
+		"src/bar.ts":              "export const x = 1",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	barrel := filepath.Join(dir, "src/components/index.ts")
+	for _, specs := range [][]string{ParseImports(files["src/components/index.ts"]), FileImports(barrel, []byte(files["src/components/index.ts"]))} {
+		sort.Strings(specs)
+		if want := []string{"@app/bar", "@app/foo"}; !reflect.DeepEqual(specs, want) {
+			t.Fatalf("specs = %v, want %v", specs, want)
+		}
+	}
+
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "src/bar.ts"), filepath.Join(dir, "src/foo.tsx")}
+	if got := g.OutNeighbors(barrel); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected internal edges %v, got %v", want, got)
+	}
+}
+
+func TestComputedImports(t *testing.T) {
+	src := `import a from './a';
+const b = import('./b');
+const c = import(name);
+const d = require(base + '/d');
+const e = require('./handlers/' + kind);
+const f = import(/* webpackChunkName: "f" */ './f');
+const g = import(pathFor(page));
+const h = import(` + "`./pages/${page}`" + `);
+declare function require(id: string): any;
+loader.require(dep);
+`
+	var got []string
+	for _, c := range computedImports(src) {
+		got = append(got, fmt.Sprintf("%d:%s", c.Line, c.Expr))
+	}
+	want := []string{"3:name", "4:base + '/d'", "7:pathFor(page)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("computedImports = %q, want %q", got, want)
+	}
+}
+
+func TestBuildGraph_ComputedImports(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.ts")
+	if err := os.WriteFile(a, []byte("export const load = (name: string) => import(name);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, m, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ComputedImport{{File: a, Line: 1, Expr: "name"}}
+	if !reflect.DeepEqual(m.Computed, want) {
+		t.Fatalf("full walk: Computed = %+v, want %+v", m.Computed, want)
+	}
+	_, m, err = BuildGraphFromEntriesWithConfig(context.Background(), Config{Root: dir}, []Entry{{Path: a}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Computed, want) {
+		t.Fatalf("entries: Computed = %+v, want %+v", m.Computed, want)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for spec, want := range map[string]string{
+		"react":             "react",
+		"react/jsx-runtime": "react",
+		"@acme/ui":          "@acme/ui",
+		"@acme/ui/button":   "@acme/ui",
+		"lodash/fp/map":     "lodash",
+	} {
+		if got := PackageName(spec); got != want {
+			t.Errorf("PackageName(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
+
+func TestPublicAPI(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"button.tsx":     "export function Button() {}\nexport default Button\nfunction helper() {}",
+		"card.tsx":       "export const Card = 1\nexport type CardProps = {}",
+		"icons/index.ts": "export * from '../icon'",
+		"icon.tsx":       "export const Icon = 1\nexport const Button = 2",
+		"index.ts": "// barrel\nexport * from './button'\nexport * from './icons'\n" +
+			"export { Card as Tile, type CardProps } from './card'\nexport * as hooks from './card'\n" +
+			"export { default as DefaultButton } from './button'\nexport { merge } from 'lodash'\n" +
+			"const version = '1'\nexport { version }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, _, err := BuildGraphWithConfig(context.Background(), Config{Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+	// Button comes from both export * (button and icon), so it's ambiguous.
+	want := []Export{
+		{Name: "CardProps", File: p("card.tsx")},
+		{Name: "DefaultButton", File: p("button.tsx")},
+		{Name: "Icon", File: p("icon.tsx")},
+		{Name: "Tile", File: p("card.tsx")},
+		{Name: "hooks", File: p("card.tsx")},
+		{Name: "merge", File: "pkg:lodash"},
+		{Name: "version", File: p("index.ts")},
+	}
+	if got := PublicAPI(g, p("index.ts")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("PublicAPI =\n %+v\nwant\n %+v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/philjestin/philtographer/internal/glob"
+)
+
+// DefaultSkip are the file globs skipped when Config.Skip is unset: committed
+// bundles whose contents would only add garbage pkg: edges.
+var DefaultSkip = []string{"*.min.js", "*.bundle.js"}
+
+// IgnorePragma opts a file out of the graph when it appears in a comment within the
+// first pragmaLines lines, e.g. "// @philtographer-ignore".
+const IgnorePragma = "@philtographer-ignore"
+
+const pragmaLines = 5
+
+// ReasonNotUTF8 starts the skip reason of files that aren't valid UTF-8 (a stray
+// Latin-1 or UTF-16 file): parsing them would miss imports or invent garbage ones.
+const ReasonNotUTF8 = "not valid UTF-8"
+
+// skipReason returns why path should not be parsed based on its name, or "".
+func (c Config) skipReason(path string) string {
+	patterns := c.Skip
+	if patterns == nil {
+		patterns = DefaultSkip
+	}
+	rel := path
+	if r, err := filepath.Rel(c.Root, path); err == nil {
+		rel = r
+	}
+	for _, pat := range patterns {
+		name := filepath.Base(path)
+		if strings.Contains(pat, "/") {
+			name = rel
+		}
+		if glob.Match(pat, name) {
+			return "matches skip pattern " + pat
+		}
+	}
+	return ""
+}
+
+// contentSkipReason returns why already-read contents should not be parsed, or "".
+func (c Config) contentSkipReason(data []byte) string {
+	if hasIgnorePragma(data) {
+		return "ignore pragma"
+	}
+	if i := invalidUTF8(data); i >= 0 {
+		return fmt.Sprintf("%s (bad byte 0x%02x at offset %d)", ReasonNotUTF8, data[i], i)
+	}
+	if c.MaxLineLength > 0 && longestLine(data) > c.MaxLineLength {
+		return fmt.Sprintf("looks minified (line longer than %d)", c.MaxLineLength)
+	}
+	return ""
+}
+
+// invalidUTF8 returns the offset of the first byte in data that isn't part of a
+// valid UTF-8 sequence, or -1.
+func invalidUTF8(data []byte) int {
+	if utf8.Valid(data) {
+		return -1
+	}
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+func longestLine(data []byte) int {
+	longest := 0
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			i = len(data)
+		}
+		if i > longest {
+			longest = i
+		}
+		if i == len(data) {
+			break
+		}
+		data = data[i+1:]
+	}
+	return longest
+}
+
+// hasIgnorePragma reports whether IgnorePragma appears in a comment near the top of data.
+func hasIgnorePragma(data []byte) bool {
+	for i := 0; i < pragmaLines && len(data) > 0; i++ {
+		line := data
+		if j := bytes.IndexByte(data, '\n'); j >= 0 {
+			line, data = data[:j], data[j+1:]
+		} else {
+			data = nil
+		}
+		line = bytes.TrimSpace(line)
+		isComment := bytes.HasPrefix(line, []byte("//")) || bytes.HasPrefix(line, []byte("/*")) || bytes.HasPrefix(line, []byte("*"))
+		if isComment && bytes.Contains(line, []byte(IgnorePragma)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package scan
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ResolveStats counts which path Resolver.Resolve took for each specifier, so a
+// regression (say, aliases silently no longer matching) shows up as a shifted
+// breakdown rather than a pile of new pkg: nodes.
+type ResolveStats struct {
+	Relative       int // relative or absolute specs resolved to a file
+	RelativeFailed int // relative or absolute specs that matched no file
+	Subpath        int // "#..." specs resolved through package.json "imports"
+	ImportMap      int // resolved through the import map
+	Alias          int // resolved through the root tsconfig "paths"
+	Workspace      int // resolved into a workspace package
+	Nearest        int // resolved through the nearest tsconfig's "paths" or "baseUrl"
+	BaseURL        int // resolved under the root tsconfig "baseUrl" or one of Resolver.BaseDirs
+	PnP            int // resolved into a Yarn Plug'n'Play package
+	Bare           int // left as "pkg:" externals
+	AliasMissed    int // of Bare: matched a root "paths" pattern, but no target existed
+	Loops          int // failed with ErrResolveLoop
+}
+
+// Total is the number of specifiers resolved (or attempted).
+func (s ResolveStats) Total() int {
+	return s.Relative + s.RelativeFailed + s.Subpath + s.ImportMap + s.Alias + s.Workspace + s.Nearest + s.BaseURL + s.PnP + s.Bare + s.Loops
+}
+
+// Coverage is the fraction of relative and absolute specs that resolved to a file,
+// Relative / (Relative + RelativeFailed). It is 1 when there were none.
+func (s ResolveStats) Coverage() float64 {
+	if s.Relative+s.RelativeFailed == 0 {
+		return 1
+	}
+	return float64(s.Relative) / float64(s.Relative+s.RelativeFailed)
+}
+
+// String formats s as a one-line breakdown.
+func (s ResolveStats) String() string {
+	return fmt.Sprintf("relative=%d (%d failed) import-map=%d alias=%d workspace=%d nearest-tsconfig=%d baseUrl=%d pnp=%d subpath=%d bare=%d (%d alias misses) loops=%d",
+		s.Relative, s.RelativeFailed, s.ImportMap, s.Alias, s.Workspace, s.Nearest, s.BaseURL, s.PnP, s.Subpath, s.Bare, s.AliasMissed, s.Loops)
+}
+
+// resolveCounters is the concurrent-safe form of ResolveStats kept by a Resolver.
+type resolveCounters struct {
+	relative, relativeFailed, subpath, importMap, alias, workspace, nearest, baseURL, pnp, bare, aliasMissed, loops atomic.Int64
+}
+
+// Stats returns how the specifiers resolved so far were handled.
+func (r *Resolver) Stats() ResolveStats {
+	c := &r.counts
+	return ResolveStats{
+		Relative:       int(c.relative.Load()),
+		RelativeFailed: int(c.relativeFailed.Load()),
+		Subpath:        int(c.subpath.Load()),
+		ImportMap:      int(c.importMap.Load()),
+		Alias:          int(c.alias.Load()),
+		Workspace:      int(c.workspace.Load()),
+		Nearest:        int(c.nearest.Load()),
+		BaseURL:        int(c.baseURL.Load()),
+		PnP:            int(c.pnp.Load()),
+		Bare:           int(c.bare.Load()),
+		AliasMissed:    int(c.aliasMissed.Load()),
+		Loops:          int(c.loops.Load()),
+	}
+}
+
+// matchesAlias reports whether spec matches any root "paths" pattern.
+func (r *Resolver) matchesAlias(spec string) bool {
+	for pat := range r.paths {
+		if pat == spec {
+			return true
+		}
+		if head, _, ok := strings.Cut(pat, "*"); ok && strings.HasPrefix(spec, head) {
+			return true
+		}
+	}
+	return false
+}
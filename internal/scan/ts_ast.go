@@ -10,18 +10,48 @@ import (
 	ts "github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
-// parseImportsAST extracts module specifiers using tree-sitter (TS/TSX), covering
-// import statements, export ... from, require(), and dynamic import().
-// On parse failure, it returns nil to allow callers to fall back to regex.
-func parseImportsAST(path string, content []byte) []string {
+// FileImports returns the module specifiers imported by the file at path, whose
+// contents are data. Registered preprocessors run first; the result is parsed with
+// tree-sitter, falling back to ParseImports (what the graph builders use) when
+// that fails.
+func FileImports(path string, data []byte) []string {
+	src := preprocess(path, data)
+	if specs := parseImportsAST(path, src); specs != nil {
+		return specs
+	}
+	return ParseImports(string(src))
+}
+
+// ParseSource parses TS/JS source with tree-sitter: the TypeScript grammar for
+// ".ts" files and TSX for everything else. A .ts file that doesn't parse cleanly is
+// retried as TSX, since some .ts files contain JSX against convention; the TSX tree
+// is kept only when it has no errors (a <T>x cast is an error in TSX). Returns nil
+// if parsing fails outright.
+func ParseSource(path string, content []byte) *sitter.Tree {
 	parser := sitter.NewParser()
-	ext := strings.ToLower(filepath.Ext(path))
-	if ext == ".ts" {
-		parser.SetLanguage(ts.GetLanguage())
-	} else {
+	if strings.ToLower(filepath.Ext(path)) != ".ts" {
 		parser.SetLanguage(tsx.GetLanguage())
+		return parser.Parse(nil, content)
 	}
+	parser.SetLanguage(ts.GetLanguage())
 	tree := parser.Parse(nil, content)
+	if tree != nil && !tree.RootNode().HasError() {
+		return tree
+	}
+	parser.SetLanguage(tsx.GetLanguage())
+	if retry := parser.Parse(nil, content); retry != nil && !retry.RootNode().HasError() {
+		return retry
+	}
+	return tree
+}
+
+// parseImportsAST extracts module specifiers using tree-sitter (TS/TSX), covering
+// import statements, export ... from, require(), and dynamic import(), including
+// concatenated paths (see IsApproximate).
+// On parse failure, it returns nil to allow callers to fall back to regex.
+func parseImportsAST(path string, content []byte) []string {
+	content = NormalizeSource(content)
+	tree := ParseSource(path, content)
 	if tree == nil {
 		return nil
 	}
@@ -50,7 +80,8 @@ func parseImportsAST(path string, content []byte) []string {
 			if n.NamedChildCount() >= 2 {
 				callee := n.NamedChild(0)
 				args := n.NamedChild(1)
-				if callee != nil && (callee.Type() == "identifier" && (nodeText(content, callee) == "require" || nodeText(content, callee) == "import")) {
+				// the grammar gives import() its own "import" callee node
+				if callee != nil && (callee.Type() == "import" || callee.Type() == "identifier" && (nodeText(content, callee) == "require" || nodeText(content, callee) == "import")) {
 					// first string literal argument
 					for i := 0; i < int(args.NamedChildCount()); i++ {
 						a := args.NamedChild(i)
@@ -61,6 +92,13 @@ func parseImportsAST(path string, content []byte) []string {
 							}
 							break
 						}
+						// require("./handlers/" + name): keep the static prefix as a glob
+						if a.Type() == "binary_expression" {
+							if spec := approximateSpec(concatPrefix(content, a)); spec != "" {
+								out[spec] = struct{}{}
+							}
+							break
+						}
 					}
 				}
 			}
@@ -78,8 +116,8 @@ func parseImportsAST(path string, content []byte) []string {
 	filtered := make([]string, 0, len(specs))
 	for _, module := range specs {
 		l := strings.ToLower(module)
-		if strings.Contains(module, "*") ||
-			strings.HasSuffix(l, ".css") || strings.HasSuffix(l, ".scss") || strings.HasSuffix(l, ".less") || strings.HasSuffix(l, ".yml") ||
+		if (strings.Contains(module, "*") && !IsApproximate(module)) ||
+			(!isCSSModule(module) && (strings.HasSuffix(l, ".css") || strings.HasSuffix(l, ".scss"))) || strings.HasSuffix(l, ".less") || strings.HasSuffix(l, ".yml") ||
 			strings.HasSuffix(l, ".jpg") || strings.HasSuffix(l, ".jpeg") || strings.HasSuffix(l, ".png") || strings.HasSuffix(l, ".gif") || strings.HasSuffix(l, ".svg") ||
 			strings.HasSuffix(l, ".mp3") || strings.HasSuffix(l, ".mp4") {
 			continue
@@ -89,6 +127,24 @@ func parseImportsAST(path string, content []byte) []string {
 	return filtered
 }
 
+// concatPrefix returns the string literal a chain of + concatenations starts with
+// ("./handlers/" for "./handlers/" + name + ".js"), or "".
+func concatPrefix(src []byte, n *sitter.Node) string {
+	for n.Type() == "binary_expression" {
+		if op := n.ChildByFieldName("operator"); op == nil || op.Type() != "+" {
+			return ""
+		}
+		n = n.ChildByFieldName("left")
+		if n == nil {
+			return ""
+		}
+	}
+	if n.Type() != "string" {
+		return ""
+	}
+	return strings.Trim(nodeText(src, n), "'\"")
+}
+
 func nodeText(src []byte, n *sitter.Node) string {
 	if n == nil {
 		return ""
@@ -2,11 +2,21 @@ package scan
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 )
 
+// ErrResolveLoop reports a symlink or directory loop hit while resolving a specifier.
+var ErrResolveLoop = errors.New("resolution loop")
+
+// maxConfigDepth bounds the upward walk looking for the nearest tsconfig.
+const maxConfigDepth = 64
+
 // tsConfigCompiler models the subset of tsconfig we care about.
 type tsConfigCompiler struct {
 	CompilerOptions struct {
@@ -15,23 +25,79 @@ type tsConfigCompiler struct {
 	} `json:"compilerOptions"`
 }
 
+// DefaultExtensions is the order source extensions and index files are probed in
+// when a Resolver has no Extensions set.
+var DefaultExtensions = []string{".ts", ".tsx", ".js", ".jsx"}
+
 // Resolver loads tsconfig paths and resolves module specifiers to files.
+// It is safe for concurrent use.
 type Resolver struct {
 	root    string
 	baseDir string // root/baseUrl
 	paths   map[string][]string
+
+	// Extensions overrides DefaultExtensions: the order extensionless specs and
+	// directory index files are probed in. Set it before resolving.
+	Extensions []string
+	// OnAmbiguousIndex, when set, is called whenever a directory resolves to one of
+	// several index files (e.g. index.ts and index.tsx). It may be called
+	// concurrently and repeatedly for the same directory.
+	OnAmbiguousIndex func(AmbiguousIndex)
+	// Workspaces maps workspace package names to their directories (see
+	// FindWorkspaces), so bare imports of them resolve to source files instead of
+	// pkg: nodes. Set it before resolving.
+	Workspaces map[string]string
+	// ImportMap, when set, maps bare specs to local files before tsconfig "paths"
+	// are consulted (see LoadImportMap). Set it before resolving.
+	ImportMap *ImportMap
+	// BaseDirs are further directories bare specs are probed under, in order, when
+	// they aren't found under baseUrl. Relative ones are relative to root. Set it
+	// before resolving.
+	BaseDirs []string
+	// PnP, when set, resolves bare specs that nothing else matched into the
+	// packages of a Yarn Plug'n'Play install (see LoadPnP). Set it before resolving.
+	PnP *PnP
+
+	counts resolveCounters
+
+	// configs caches loadCompilerAt per directory for the nearest-tsconfig walk, and
+	// realDirs each directory's symlink-free path for the same walk.
+	mu       sync.RWMutex
+	configs  map[string]compilerConfig
+	realDirs map[string]realDir
+}
+
+// realDir is the cached result of filepath.EvalSymlinks for one directory.
+type realDir struct {
+	path string
+	loop bool // EvalSymlinks hit a symlink loop
+}
+
+// AmbiguousIndex is a directory import with more than one index file; Chosen is the
+// first in extension order, Others are the rest.
+type AmbiguousIndex struct {
+	Dir    string
+	Chosen string
+	Others []string
+}
+
+// compilerConfig is the cached result of loadCompilerAt for one directory.
+type compilerConfig struct {
+	baseDir string
+	paths   map[string][]string
+	ok      bool
 }
 
 // NewResolver loads tsconfig.base.json or tsconfig.json under root.
 func NewResolver(root string) *Resolver {
-	r := &Resolver{root: root}
+	r := &Resolver{root: root, configs: map[string]compilerConfig{}, realDirs: map[string]realDir{}}
 	// Determine tsconfig path preference
 	try := []string{"tsconfig.base.json", "tsconfig.json"}
 	var cfg tsConfigCompiler
 	for _, name := range try {
 		p := filepath.Join(root, name)
 		if b, err := os.ReadFile(p); err == nil {
-			_ = json.Unmarshal(b, &cfg)
+			_ = json.Unmarshal(stripJSONC(b), &cfg)
 			break
 		}
 	}
@@ -46,25 +112,70 @@ func NewResolver(root string) *Resolver {
 }
 
 // Resolve resolves relative, absolute, alias, and bare specs.
-// Returns "pkg:<name>" for bare specs with no alias.
+// Returns "pkg:<name>" for bare specs with no alias. Each call is counted in Stats.
 func (r *Resolver) Resolve(fromFile, spec string) (string, error) {
+	spec, _ = splitSpecSuffix(spec)
+	c := &r.counts
 	// Relative or absolute handled via file probing
 	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/") {
-		return resolveFile(fromFile, spec)
+		to, err := r.resolveFile(fromFile, spec)
+		switch {
+		case errors.Is(err, ErrResolveLoop):
+			c.loops.Add(1)
+		case err != nil:
+			c.relativeFailed.Add(1)
+		default:
+			c.relative.Add(1)
+		}
+		return to, err
+	}
+	// Subpath imports ("#internal/foo") come from the nearest package.json "imports" field
+	if strings.HasPrefix(spec, "#") {
+		if to, ok := r.resolvePackageImport(fromFile, spec); ok {
+			c.subpath.Add(1)
+			return to, nil
+		}
+	}
+	// Try the import map ("lit" -> "/vendor/lit/index.js")
+	if to, ok := r.resolveImportMap(fromFile, spec); ok {
+		c.importMap.Add(1)
+		return to, nil
 	}
 	// Try alias patterns from tsconfig paths
 	if to, ok := r.resolveAlias(spec); ok {
+		c.alias.Add(1)
+		return to, nil
+	}
+	// Try workspace packages ("@acme/ui", "@acme/ui/button")
+	if to, ok := r.resolveWorkspace(spec); ok {
+		c.workspace.Add(1)
 		return to, nil
 	}
 	// Try nearest tsconfig.json/tsconfig.base.json up from fromFile directory
-	if to, ok := r.resolveWithNearest(fromFile, spec); ok {
+	if to, ok, err := r.resolveWithNearest(fromFile, spec); err != nil {
+		c.loops.Add(1)
+		return "", err
+	} else if ok {
+		c.nearest.Add(1)
 		return to, nil
 	}
 	// Try baseUrl fallback (treat bare spec as relative to baseDir)
 	if to := r.resolveFromBase(spec); to != "" {
+		c.baseURL.Add(1)
 		return to, nil
 	}
+	// Try Yarn Plug'n'Play packages (files inside .yarn/cache zips)
+	if r.PnP != nil {
+		if to, ok := r.PnP.resolve(fromFile, spec); ok {
+			c.pnp.Add(1)
+			return to, nil
+		}
+	}
 	// Bare package: leave tagged
+	c.bare.Add(1)
+	if r.matchesAlias(spec) {
+		c.aliasMissed.Add(1)
+	}
 	return "pkg:" + spec, nil
 }
 
@@ -101,54 +212,58 @@ func (r *Resolver) resolveAlias(spec string) (string, bool) {
 	return "", false
 }
 
-// resolveFromBase tries to resolve a bare spec under baseUrl directory.
+// resolveFromBase tries to resolve a bare spec under the baseUrl directory, then
+// under each of BaseDirs.
 func (r *Resolver) resolveFromBase(spec string) string {
-	if r.baseDir == "" {
-		return ""
+	if to := r.resolveFromBaseDir(r.baseDir, spec); to != "" {
+		return to
 	}
-	// Join baseDir with spec and probe like resolveFile would for a relative path
-	cand := filepath.Clean(filepath.Join(r.baseDir, spec))
-	// Exact file
-	if info, err := os.Stat(cand); err == nil && !info.IsDir() {
-		return cand
-	}
-	// If directory, try index.*
-	extensions := []string{".ts", ".tsx", ".js", ".jsx"}
-	if info, err := os.Stat(cand); err == nil && info.IsDir() {
-		for _, extension := range extensions {
-			try := filepath.Join(cand, "index"+extension)
-			if info2, err2 := os.Stat(try); err2 == nil && !info2.IsDir() {
-				return try
-			}
-		}
-	}
-	// If no extension, try appending
-	if filepath.Ext(cand) == "" {
-		for _, extension := range extensions {
-			try := cand + extension
-			if info, err := os.Stat(try); err == nil && !info.IsDir() {
-				return try
-			}
+	for _, dir := range r.BaseDirs {
+		if to := r.resolveFromBaseDir(r.rootPath(dir), spec); to != "" {
+			return to
 		}
 	}
 	return ""
 }
 
+// rootPath returns p made absolute against the resolver root.
+func (r *Resolver) rootPath(p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(r.root, p)
+}
+
 // resolveWithNearest tries to load the nearest tsconfig.* above fromFile and resolve using its paths/baseUrl.
-func (r *Resolver) resolveWithNearest(fromFile, spec string) (string, bool) {
+// The walk skips directories whose real path it has already tried, so a symlinked ancestor
+// doesn't consult the same configs twice. A symlink loop, or exceeding maxConfigDepth, is
+// reported as ErrResolveLoop.
+func (r *Resolver) resolveWithNearest(fromFile, spec string) (string, bool, error) {
 	dir := filepath.Dir(fromFile)
 	stop := r.root
-	for {
-		baseDir, paths, ok := loadCompilerAt(dir)
-		if ok {
-			// direct match
-			if to := resolveWithPaths(baseDir, paths, spec); to != "" {
-				return to, true
-			}
-			// baseUrl fallback
-			if baseDir != "" {
-				if to := resolveFromBaseDir(baseDir, spec); to != "" {
-					return to, true
+	seen := map[string]bool{}
+	for depth := 0; ; depth++ {
+		if depth > maxConfigDepth {
+			return "", false, fmt.Errorf("%w: more than %d directories above %q resolving %q", ErrResolveLoop, maxConfigDepth, fromFile, spec)
+		}
+		rd := r.realDirOf(dir)
+		if rd.loop {
+			return "", false, fmt.Errorf("%w: symlink loop at %s resolving %q", ErrResolveLoop, dir, spec)
+		}
+		if !seen[rd.path] {
+			seen[rd.path] = true
+			cc := r.compilerAt(dir)
+			baseDir, paths := cc.baseDir, cc.paths
+			if cc.ok {
+				// direct match
+				if to := r.resolveWithPaths(baseDir, paths, spec); to != "" {
+					return to, true, nil
+				}
+				// baseUrl fallback
+				if baseDir != "" {
+					if to := r.resolveFromBaseDir(baseDir, spec); to != "" {
+						return to, true, nil
+					}
 				}
 			}
 		}
@@ -157,7 +272,62 @@ func (r *Resolver) resolveWithNearest(fromFile, spec string) (string, bool) {
 		}
 		dir = filepath.Dir(dir)
 	}
-	return "", false
+	return "", false, nil
+}
+
+// realDirOf returns the (cached) symlink-free path of dir. A directory that can't be
+// evaluated for another reason than a loop stands for itself.
+func (r *Resolver) realDirOf(dir string) realDir {
+	r.mu.RLock()
+	rd, hit := r.realDirs[dir]
+	r.mu.RUnlock()
+	if hit {
+		return rd
+	}
+	rd.path = dir
+	if p, err := filepath.EvalSymlinks(dir); err == nil {
+		rd.path = p
+	} else if errors.Is(err, syscall.ELOOP) {
+		rd.loop = true
+	}
+	r.mu.Lock()
+	r.realDirs[dir] = rd
+	r.mu.Unlock()
+	return rd
+}
+
+// compilerAt returns the (cached) tsconfig settings in dir.
+func (r *Resolver) compilerAt(dir string) compilerConfig {
+	r.mu.RLock()
+	cc, hit := r.configs[dir]
+	r.mu.RUnlock()
+	if hit {
+		return cc
+	}
+	baseDir, paths, ok := loadCompilerAt(dir)
+	cc = compilerConfig{baseDir: baseDir, paths: paths, ok: ok}
+	r.mu.Lock()
+	r.configs[dir] = cc
+	r.mu.Unlock()
+	return cc
+}
+
+// Warm loads and caches the tsconfig files in every directory from each of files
+// up to the resolver root, so later Resolve calls for those files (and their
+// siblings) don't touch the filesystem for config lookups. Useful for long-running
+// processes that resolve repeatedly.
+func (r *Resolver) Warm(files []string) {
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		for depth := 0; depth <= maxConfigDepth; depth++ {
+			r.compilerAt(dir)
+			r.realDirOf(dir)
+			if dir == r.root || dir == filepath.Dir(dir) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
 }
 
 // loadCompilerAt reads tsconfig.base.json or tsconfig.json in dir.
@@ -167,7 +337,7 @@ func loadCompilerAt(dir string) (string, map[string][]string, bool) {
 	for _, name := range try {
 		p := filepath.Join(dir, name)
 		if b, err := os.ReadFile(p); err == nil {
-			if json.Unmarshal(b, &cfg) == nil {
+			if json.Unmarshal(stripJSONC(b), &cfg) == nil {
 				base := dir
 				if cfg.CompilerOptions.BaseURL != "" {
 					base = filepath.Clean(filepath.Join(dir, cfg.CompilerOptions.BaseURL))
@@ -180,13 +350,13 @@ func loadCompilerAt(dir string) (string, map[string][]string, bool) {
 }
 
 // resolveWithPaths replicates alias resolution against a provided paths map and baseDir.
-func resolveWithPaths(baseDir string, paths map[string][]string, spec string) string {
+func (r *Resolver) resolveWithPaths(baseDir string, paths map[string][]string, spec string) string {
 	if len(paths) == 0 {
 		return ""
 	}
 	if globs, ok := paths[spec]; ok {
 		for _, g := range globs {
-			if to := resolveFromBaseDir(baseDir, g); to != "" {
+			if to := r.resolveFromBaseDir(baseDir, g); to != "" {
 				return to
 			}
 		}
@@ -202,7 +372,7 @@ func resolveWithPaths(baseDir string, paths map[string][]string, spec string) st
 		tail := strings.TrimPrefix(spec, head)
 		for _, g := range globs {
 			repl := strings.ReplaceAll(g, "*", tail)
-			if to := resolveFromBaseDir(baseDir, repl); to != "" {
+			if to := r.resolveFromBaseDir(baseDir, repl); to != "" {
 				return to
 			}
 		}
@@ -210,26 +380,35 @@ func resolveWithPaths(baseDir string, paths map[string][]string, spec string) st
 	return ""
 }
 
-// resolveFromBaseDir mirrors resolveFromBase using provided baseDir.
-func resolveFromBaseDir(baseDir, spec string) string {
+// resolveFromBaseDir probes spec as a path under baseDir.
+func (r *Resolver) resolveFromBaseDir(baseDir, spec string) string {
 	if baseDir == "" {
 		return ""
 	}
-	cand := filepath.Clean(filepath.Join(baseDir, spec))
-	if info, err := os.Stat(cand); err == nil && !info.IsDir() {
-		return cand
+	return r.probe(filepath.Clean(filepath.Join(baseDir, spec)))
+}
+
+// extensions returns the probe order: Extensions, or DefaultExtensions when unset.
+func (r *Resolver) extensions() []string {
+	if len(r.Extensions) > 0 {
+		return r.Extensions
 	}
-	extensions := []string{".ts", ".tsx", ".js", ".jsx"}
-	if info, err := os.Stat(cand); err == nil && info.IsDir() {
-		for _, extension := range extensions {
-			try := filepath.Join(cand, "index"+extension)
-			if info2, err2 := os.Stat(try); err2 == nil && !info2.IsDir() {
-				return try
-			}
+	return DefaultExtensions
+}
+
+// probe resolves cand as a file, a directory's index file, or (when cand has no
+// extension) cand plus a source extension, in that order.
+func (r *Resolver) probe(cand string) string {
+	if info, err := os.Stat(cand); err == nil {
+		if !info.IsDir() {
+			return cand
+		}
+		if to := r.probeIndex(cand); to != "" {
+			return to
 		}
 	}
 	if filepath.Ext(cand) == "" {
-		for _, extension := range extensions {
+		for _, extension := range r.extensions() {
 			try := cand + extension
 			if info, err := os.Stat(try); err == nil && !info.IsDir() {
 				return try
@@ -239,54 +418,66 @@ func resolveFromBaseDir(baseDir, spec string) string {
 	return ""
 }
 
+// probeIndex returns dir's index file, the first in extension order, reporting the
+// directory to OnAmbiguousIndex when it has more than one.
+func (r *Resolver) probeIndex(dir string) string {
+	var found []string
+	for _, extension := range r.extensions() {
+		try := filepath.Join(dir, "index"+extension)
+		if info, err := os.Stat(try); err == nil && !info.IsDir() {
+			found = append(found, try)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	if len(found) > 1 && r.OnAmbiguousIndex != nil {
+		r.OnAmbiguousIndex(AmbiguousIndex{Dir: dir, Chosen: found[0], Others: found[1:]})
+	}
+	return found[0]
+}
+
 // probeAliasTarget resolves a tsconfig path mapping value to a concrete file.
 func (r *Resolver) probeAliasTarget(target string) string {
 	// Targets are relative to baseDir
-	cand := filepath.Clean(filepath.Join(r.baseDir, target))
-	// Reuse file probing from resolveFile logic by faking a fromFile in baseDir
-	if to, err := resolveFile(filepath.Join(r.baseDir, "index.ts"), relFromBase(r.baseDir, cand)); err == nil && to != "" {
-		return to
-	}
-	return ""
+	return r.probe(filepath.Clean(filepath.Join(r.baseDir, target)))
 }
 
-func relFromBase(base, abs string) string {
-	if filepath.IsAbs(abs) {
-		if rel, err := filepath.Rel(filepath.Dir(base), abs); err == nil && !strings.HasPrefix(rel, ".") {
-			return "./" + rel
-		}
-		return abs
+// --- helpers shared with legacy Resolve ---
+
+// splitSpecSuffix separates a bundler query or hash suffix ("./worker?worker",
+// "./icon.svg?react", "./x#frag") from the path part of spec. A leading "#" is a
+// package.json subpath import, not a suffix.
+func splitSpecSuffix(spec string) (path, suffix string) {
+	if i := strings.IndexAny(spec, "?#"); i > 0 {
+		return spec[:i], spec[i:]
 	}
-	return abs
+	return spec, ""
 }
 
-// --- helpers shared with legacy Resolve ---
-
-func resolveFile(fromFile, spec string) (string, error) {
+func (r *Resolver) resolveFile(fromFile, spec string) (string, error) {
+	spec, _ = splitSpecSuffix(spec)
 	base := filepath.Dir(fromFile)
 	candidate := filepath.Clean(filepath.Join(base, spec))
-	if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
-		return candidate, nil
+	if _, err := os.Stat(candidate); errors.Is(err, syscall.ELOOP) {
+		return "", fmt.Errorf("%w: symlink loop at %s resolving %q from %q", ErrResolveLoop, candidate, spec, fromFile)
 	}
-	extensions := []string{".ts", ".tsx", ".js", ".jsx"}
+	if to := r.probe(candidate); to != "" {
+		return to, nil
+	}
+	// Build attempts list for error context
+	attempts := []string{candidate}
 	if info, err := os.Stat(candidate); err == nil && info.IsDir() {
-		for _, extension := range extensions {
-			try := filepath.Join(candidate, "index"+extension)
-			if info2, err2 := os.Stat(try); err2 == nil && !info2.IsDir() {
-				return try, nil
-			}
+		for _, extension := range r.extensions() {
+			attempts = append(attempts, filepath.Join(candidate, "index"+extension))
 		}
 	}
 	if filepath.Ext(candidate) == "" {
-		for _, extension := range extensions {
-			try := candidate + extension
-			if info, err := os.Stat(try); err == nil && !info.IsDir() {
-				return try, nil
-			}
+		for _, extension := range r.extensions() {
+			attempts = append(attempts, candidate+extension)
 		}
 	}
-	// Build attempts list for error context
-	return "", os.ErrNotExist
+	return "", fmt.Errorf("could not resolve %q from %q; tried: %v: %w", spec, fromFile, attempts, os.ErrNotExist)
 }
 
 // WatchDirs returns directories implied by paths mappings to help watchers include alias targets.
@@ -295,6 +486,9 @@ func (r *Resolver) WatchDirs() []string {
 	if r.baseDir != "" {
 		dirs[r.baseDir] = struct{}{}
 	}
+	for _, d := range r.BaseDirs {
+		dirs[r.rootPath(d)] = struct{}{}
+	}
 	for _, globs := range r.paths {
 		for _, g := range globs {
 			cut := g
@@ -0,0 +1,98 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walkRoot returns the directory a full scan walks: Root, or Only when set.
+func (c Config) walkRoot() (string, error) {
+	if c.Only == "" {
+		return c.Root, nil
+	}
+	dir := c.Only
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.Root, dir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("only: %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// walkSources calls fn with every source file under dir, skipping dot directories,
+// node_modules, dist, and build. With followSymlinks it also descends into
+// symlinked directories, visiting each real directory once. The walk stops at
+// the first error from fn (or once ctx is done) and returns it.
+func walkSources(ctx context.Context, dir string, followSymlinks bool, fn func(path string) error) error {
+	// realDirs holds the resolved path of every directory walked so far when
+	// following symlinks, so each real directory is visited once.
+	realDirs := map[string]bool{}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			// stop walking once the caller has given up on the scan
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() {
+				// skip junk (but never the walk root itself, e.g. ".")
+				name := d.Name()
+				if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist" || name == "build") {
+					return filepath.SkipDir
+				}
+				if followSymlinks {
+					if realDir, err := filepath.EvalSymlinks(path); err == nil {
+						if realDirs[realDir] {
+							return filepath.SkipDir
+						}
+						realDirs[realDir] = true
+					}
+				}
+				return nil
+			}
+			if d.Type()&os.ModeSymlink != 0 && followSymlinks {
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					// WalkDir doesn't descend into a symlink root; the trailing
+					// separator makes it resolve the link first.
+					return walk(path + string(filepath.Separator))
+				}
+			}
+			if isSource(path) {
+				return fn(path)
+			}
+			return nil
+		})
+	}
+	return walk(dir)
+}
+
+// ListFiles walks the tree as BuildGraphWithConfig would (Only, FollowSymlinks,
+// and the Skip globs all apply) and returns the files it would parse, plus those
+// skipped by name, without reading any of them. Files skipped for their contents
+// (the ignore pragma, MaxLineLength) can't be told apart without reading, so they
+// are listed.
+func ListFiles(ctx context.Context, cfg Config) ([]string, []Skipped, error) {
+	dir, err := cfg.walkRoot()
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	var skipped []Skipped
+	err = walkSources(ctx, dir, cfg.FollowSymlinks, func(path string) error {
+		if reason := cfg.skipReason(path); reason != "" {
+			skipped = append(skipped, Skipped{File: path, Reason: reason})
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, skipped, err
+}
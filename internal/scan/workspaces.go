@@ -0,0 +1,118 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/philjestin/philtographer/internal/glob"
+)
+
+// FindWorkspaces maps package names to directories for the workspace package
+// globs in patterns (e.g. "packages/*", relative to root). Patterns starting with
+// "!" exclude directories the others match. Matches without a package.json, or
+// whose package.json has no name, are ignored; when two directories claim a name,
+// the first in lexical order wins.
+func FindWorkspaces(root string, patterns []string) map[string]string {
+	excluded := map[string]bool{}
+	for _, pat := range patterns {
+		if neg, ok := strings.CutPrefix(pat, "!"); ok {
+			dirs, _ := glob.Expand(root, neg)
+			for _, dir := range dirs {
+				excluded[dir] = true
+			}
+		}
+	}
+	out := map[string]string{}
+	for _, pat := range patterns {
+		if strings.HasPrefix(pat, "!") {
+			continue
+		}
+		dirs, _ := glob.Expand(root, pat)
+		for _, dir := range dirs {
+			if excluded[dir] {
+				continue
+			}
+			pkg, ok := readPackageJSON(dir)
+			if !ok || pkg.Name == "" {
+				continue
+			}
+			if _, taken := out[pkg.Name]; !taken {
+				out[pkg.Name] = dir
+			}
+		}
+	}
+	return out
+}
+
+// DetectWorkspaces returns the workspace package globs declared at root: the
+// "workspaces" field of its package.json (npm and Yarn, as a list or as
+// {"packages": [...]}) and the "packages" list of pnpm-workspace.yaml. It returns
+// nil when neither declares any.
+func DetectWorkspaces(root string) []string {
+	var out []string
+	if pkg, ok := readPackageJSON(root); ok && len(pkg.Workspaces) > 0 {
+		var list []string
+		var obj struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(pkg.Workspaces, &list) == nil {
+			out = append(out, list...)
+		} else if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+			out = append(out, obj.Packages...)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var pnpm struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &pnpm) == nil {
+			out = append(out, pnpm.Packages...)
+		}
+	}
+	return out
+}
+
+// workspacePatterns returns the configured workspace globs or, when there are
+// none and AutoWorkspaces is set, the ones DetectWorkspaces finds at Root.
+func (c Config) workspacePatterns() []string {
+	if len(c.Workspaces) > 0 || !c.AutoWorkspaces {
+		return c.Workspaces
+	}
+	return DetectWorkspaces(c.Root)
+}
+
+// resolveWorkspace resolves a bare spec naming a workspace package. The package
+// root maps to the index file of its source dir ("src" when present, else the
+// package dir itself); a subpath ("@acme/ui/button") is joined onto the source dir
+// and probed like any other path, falling back to the package dir.
+func (r *Resolver) resolveWorkspace(spec string) (string, bool) {
+	if len(r.Workspaces) == 0 {
+		return "", false
+	}
+	name := PackageName(spec)
+	dir, ok := r.Workspaces[name]
+	if !ok {
+		return "", false
+	}
+	rest := strings.TrimPrefix(spec[len(name):], "/")
+	bases := []string{dir}
+	if info, err := os.Stat(filepath.Join(dir, "src")); err == nil && info.IsDir() {
+		bases = []string{filepath.Join(dir, "src"), dir}
+	}
+	for _, base := range bases {
+		if rest == "" {
+			if to := r.probeIndex(base); to != "" {
+				return to, true
+			}
+			continue
+		}
+		if to := r.probe(filepath.Join(base, filepath.FromSlash(rest))); to != "" {
+			return to, true
+		}
+	}
+	return "", false
+}
@@ -5,15 +5,61 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/philjestin/philtographer/internal/graph"
 )
 
+// Options tunes BuildComponentGraphWithOptions. The zero value matches BuildComponentGraphFromEntries.
+type Options struct {
+	// Progress, when non-nil, receives snapshots of (visitedFiles, edgesAdded, filesEnqueued).
+	Progress func(visited, edges, queued int)
+
+	// SplitComponents makes each declared component its own node ("file.tsx#Name") and
+	// connects component nodes instead of files. Usages that can't be attributed to a
+	// specific component on either side fall back to the file node.
+	SplitComponents bool
+
+	// ExternalUsages keeps JSX usages of identifiers imported from packages (e.g.
+	// <Chakra.Box> from "@chakra-ui/react") as edges to the external "pkg:" node,
+	// showing which screens use which UI libraries. By default they are dropped.
+	ExternalUsages bool
+
+	// ImportEdges also records components that a file imports but never renders,
+	// tagging edges graph.EdgeImported, and tags JSX usages graph.EdgeRendered.
+	// Import-only targets are not walked further. Only imports that resolve to a
+	// declared component count, so type and helper imports don't show up. In file
+	// mode an edge is "imported" only when nothing of the target is rendered.
+	ImportEdges bool
+
+	// Checkpoint, when non-nil, receives a copy of the graph built so far every
+	// CheckpointEvery (default 5s) while files are being walked, so a long build can
+	// persist partial results. Import-only edges are added at the end and so are not
+	// in checkpoints; in split mode, a usage of a file not parsed yet points at the
+	// file node. Calls are serialized.
+	Checkpoint      func(*graph.Graph)
+	CheckpointEvery time.Duration
+
+	// Unresolved, when non-nil, is called once per file and identifier for component
+	// usages that couldn't be linked: capitalized JSX identifiers that are neither
+	// declared in the file nor imported from a resolvable module. Calls are serialized.
+	Unresolved func(Unresolved)
+}
+
+// Unresolved is a JSX component usage the builder couldn't link to a file.
+type Unresolved struct {
+	File   string
+	Ident  string
+	Module string // import spec the identifier came from; "" when it isn't imported
+}
+
 // BuildComponentGraphFromEntries walks reachable TSX files from entries and adds edges ComponentFile -> ImportedComponentFile when JSX uses imported identifiers.
 func BuildComponentGraphFromEntries(ctx context.Context, root string, entries []string) (*graph.Graph, error) {
-	return BuildComponentGraphFromEntriesProgress(ctx, root, entries, nil)
+	return BuildComponentGraphWithOptions(ctx, root, entries, Options{})
 }
 
 // BuildComponentGraphFromEntriesProgress is the same as BuildComponentGraphFromEntries but reports progress snapshots.
@@ -24,6 +70,11 @@ func BuildComponentGraphFromEntriesProgress(
 	entries []string,
 	progress func(visited, edges, queued int),
 ) (*graph.Graph, error) {
+	return BuildComponentGraphWithOptions(ctx, root, entries, Options{Progress: progress})
+}
+
+// BuildComponentGraphWithOptions is the general form of BuildComponentGraphFromEntries.
+func BuildComponentGraphWithOptions(ctx context.Context, root string, entries []string, opts Options) (*graph.Graph, error) {
 	g := graph.New()
 	var gmu sync.Mutex
 
@@ -35,6 +86,68 @@ func BuildComponentGraphFromEntriesProgress(
 	var enqueuedCount atomic.Int64
 	var inflight atomic.Int64
 
+	// In split mode, edges are resolved to component nodes once every reachable
+	// file has been parsed, so targets can be matched against their declarations.
+	type usage struct{ from, to, imported string }
+	var usages []usage
+	infos := map[string]FileInfo{}
+	// importOnly holds ImportEdges candidates: imports a file never renders.
+	var importOnly []usage
+
+	// addSplit adds the component nodes and usage edges collected in split mode to dst.
+	addSplit := func(dst *graph.Graph) {
+		for path, fi := range infos {
+			if len(fi.Components) == 0 {
+				dst.Touch(path)
+			}
+			for _, c := range fi.Components {
+				dst.Touch(componentNode(path, c))
+			}
+		}
+		for _, u := range usages {
+			to := u.to
+			if !isExternal(to) {
+				to = targetComponent(infos[u.to], u.to, u.imported)
+			}
+			if opts.ImportEdges {
+				dst.SetEdgeKind(u.from, to, graph.EdgeRendered)
+			} else {
+				dst.AddEdge(u.from, to)
+			}
+		}
+	}
+
+	every := opts.CheckpointEvery
+	if every <= 0 {
+		every = 5 * time.Second
+	}
+	var checkpointMu sync.Mutex
+	lastCheckpoint := time.Now()
+	// checkpoint hands opts.Checkpoint a snapshot when one is due; workers that
+	// find another checkpoint in progress skip it.
+	checkpoint := func() {
+		if opts.Checkpoint == nil || !checkpointMu.TryLock() {
+			return
+		}
+		defer checkpointMu.Unlock()
+		if time.Since(lastCheckpoint) < every {
+			return
+		}
+		lastCheckpoint = time.Now()
+		gmu.Lock()
+		var snap *graph.Graph
+		if opts.SplitComponents {
+			snap = graph.New()
+			addSplit(snap)
+		} else {
+			snap = g.Subgraph(g.Nodes())
+		}
+		gmu.Unlock()
+		opts.Checkpoint(snap)
+	}
+
+	barrels := newReexportCache()
+
 	visited := map[string]struct{}{}
 	var mu sync.Mutex
 	enqueue := func(p string) {
@@ -77,26 +190,104 @@ func BuildComponentGraphFromEntriesProgress(
 				if err == nil {
 					if fi, perr := ParseTSX(j.path, data); perr == nil {
 						gmu.Lock()
-						g.Touch(j.path)
+						if opts.SplitComponents {
+							infos[j.path] = fi
+						} else {
+							g.Touch(j.path)
+						}
 						gmu.Unlock()
 						visitedCount.Add(1)
-						for _, ident := range fi.JSXIdentifiers {
-							if to := ResolveImportedComponent(j.path, fi.ImportMap, ident); to != "" {
+						reported := map[string]bool{}
+						unresolved := func(ident string) {
+							if opts.Unresolved == nil || reported[ident] || !isComponentName(ident) || slices.Contains(fi.Components, ident) {
+								return
+							}
+							reported[ident] = true
+							gmu.Lock()
+							opts.Unresolved(Unresolved{File: j.path, Ident: ident, Module: fi.ImportMap[ident]})
+							gmu.Unlock()
+						}
+						if opts.SplitComponents {
+							for _, u := range fi.JSXUsages {
+								if to := ResolveImportedComponent(j.path, fi.ImportMap, u.Ident); to != "" {
+									if isExternal(to) {
+										if opts.ExternalUsages {
+											from := j.path
+											if u.Within != "" {
+												from = componentNode(j.path, u.Within)
+											}
+											gmu.Lock()
+											usages = append(usages, usage{from: from, to: to})
+											gmu.Unlock()
+											edgesCount.Add(1)
+										}
+										continue
+									}
+									to = barrels.resolve(to, fi.ImportNames[u.Ident])
+									from := j.path
+									if u.Within != "" {
+										from = componentNode(j.path, u.Within)
+									}
+									gmu.Lock()
+									usages = append(usages, usage{from: from, to: to, imported: fi.ImportNames[u.Ident]})
+									gmu.Unlock()
+									edgesCount.Add(1)
+									enqueue(to)
+								} else {
+									unresolved(u.Ident)
+								}
+							}
+						} else {
+							for _, ident := range fi.JSXIdentifiers {
+								if to := ResolveImportedComponent(j.path, fi.ImportMap, ident); to != "" {
+									if isExternal(to) {
+										if opts.ExternalUsages {
+											gmu.Lock()
+											g.AddEdge(j.path, to)
+											gmu.Unlock()
+											edgesCount.Add(1)
+										}
+										continue
+									}
+									to = barrels.resolve(to, fi.ImportNames[ident])
+									gmu.Lock()
+									if opts.ImportEdges {
+										g.SetEdgeKind(j.path, to, graph.EdgeRendered)
+									} else {
+										g.AddEdge(j.path, to)
+									}
+									gmu.Unlock()
+									edgesCount.Add(1)
+									enqueue(to)
+								} else {
+									unresolved(ident)
+								}
+							}
+						}
+						if opts.ImportEdges {
+							for local, imported := range fi.ImportNames {
+								if imported == "*" || !isComponentName(local) || slices.Contains(fi.JSXIdentifiers, local) {
+									continue
+								}
+								to := ResolveImportedComponent(j.path, fi.ImportMap, local)
+								if to == "" || isExternal(to) {
+									continue
+								}
+								to = barrels.resolve(to, imported)
 								gmu.Lock()
-								g.AddEdge(j.path, to)
+								importOnly = append(importOnly, usage{from: j.path, to: to, imported: imported})
 								gmu.Unlock()
-								edgesCount.Add(1)
-								enqueue(to)
 							}
 						}
 					}
 				}
-				if progress != nil {
+				if opts.Progress != nil {
 					v := int(visitedCount.Load())
 					e := int(edgesCount.Load())
 					q := int(enqueuedCount.Load())
-					progress(v, e, q)
+					opts.Progress(v, e, q)
 				}
+				checkpoint()
 				// mark this job done; if this was the last, close the queue
 				if inflight.Add(-1) == 0 {
 					close(jobs)
@@ -106,5 +297,69 @@ func BuildComponentGraphFromEntriesProgress(
 	}
 
 	wg.Wait()
+
+	if opts.SplitComponents {
+		addSplit(g)
+	}
+
+	// Import-only edges go in last so a rendered edge between the same nodes wins.
+	parsed := map[string]FileInfo{}
+	for _, u := range importOnly {
+		fi, ok := infos[u.to]
+		if !ok {
+			if fi, ok = parsed[u.to]; !ok {
+				if data, err := os.ReadFile(u.to); err == nil {
+					fi, _ = ParseTSX(u.to, data)
+				}
+				parsed[u.to] = fi
+			}
+		}
+		to := targetComponent(fi, u.to, u.imported)
+		if to == u.to {
+			// not a declared component (a type, hook, or constant)
+			continue
+		}
+		from := u.from
+		if opts.SplitComponents {
+			// an import isn't inside any component; credit the file's only
+			// component when there is one, else the file itself
+			if cs := infos[from].Components; len(cs) == 1 {
+				from = componentNode(from, cs[0])
+			}
+		} else {
+			to = u.to
+		}
+		if g.EdgeKind(from, to) == "" {
+			g.SetEdgeKind(from, to, graph.EdgeImported)
+		}
+	}
 	return g, ctx.Err()
 }
+
+// isExternal reports whether a resolved import is a package ("pkg:") node rather than a file.
+func isExternal(to string) bool {
+	return strings.HasPrefix(to, "pkg:")
+}
+
+// componentNode names the node for component name declared in file.
+func componentNode(file, name string) string {
+	return file + "#" + name
+}
+
+// targetComponent picks the component node in file that an import binding refers to,
+// falling back to the file node when the binding can't be matched to a declaration.
+func targetComponent(fi FileInfo, file, imported string) string {
+	name := imported
+	if imported == "default" {
+		name = fi.DefaultExport
+		if name == "" && len(fi.Components) == 1 {
+			name = fi.Components[0]
+		}
+	}
+	for _, c := range fi.Components {
+		if c == name {
+			return componentNode(file, c)
+		}
+	}
+	return file
+}
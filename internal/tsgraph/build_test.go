@@ -4,7 +4,11 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/philjestin/philtographer/internal/graph"
 )
 
 func write(t *testing.T, path string, content string) string {
@@ -85,3 +89,265 @@ func TestBuildComponentGraph_Cycle(t *testing.T) {
         t.Fatalf("expected 2 nodes, got %v", ns)
     }
 }
+
+func TestBuildComponentGraph_SplitComponents(t *testing.T) {
+    dir := t.TempDir()
+    a := write(t, filepath.Join(dir, "a.tsx"), `
+        import { Primary, Secondary as Alt } from './buttons'
+        export function Toolbar(){ return <Primary/> }
+        export function Footer(){ return <Alt/> }
+    `)
+    b := write(t, filepath.Join(dir, "buttons.tsx"), `
+        export function Primary(){ return null }
+        export const Secondary = () => null
+    `)
+    g, err := BuildComponentGraphWithOptions(context.Background(), dir, []string{a}, Options{SplitComponents: true})
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    want := map[string]string{
+        a + "#Toolbar": b + "#Primary",
+        a + "#Footer":  b + "#Secondary",
+    }
+    for from, to := range want {
+        out := g.OutNeighbors(from)
+        if len(out) != 1 || out[0] != to {
+            t.Fatalf("expected %s -> %s, got %v", from, to, out)
+        }
+    }
+}
+
+func TestBuildComponentGraph_StarReexportBarrel(t *testing.T) {
+	dir := t.TempDir()
+	a := write(t, filepath.Join(dir, "a.tsx"), `
+        import { Button } from './components'
+        export function A(){ return <Button/> }
+    `)
+	write(t, filepath.Join(dir, "components", "index.ts"), `
+        export * from './Button'
+    `)
+	button := write(t, filepath.Join(dir, "components", "Button.tsx"), `
+        export function Button(){ return null }
+    `)
+	g, err := BuildComponentGraphFromEntries(context.Background(), dir, []string{a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := g.OutNeighbors(a)
+	if len(out) != 1 || out[0] != button {
+		t.Fatalf("expected edge to %s through the barrel, got %v", button, out)
+	}
+
+	// the barrel is parsed once per cache, not once per usage
+	barrels := newReexportCache()
+	index := filepath.Join(dir, "components", "index.ts")
+	if to := barrels.resolve(index, "Button"); to != button {
+		t.Fatalf("expected %s, got %s", button, to)
+	}
+	if err := os.Remove(index); err != nil {
+		t.Fatal(err)
+	}
+	if to := barrels.resolve(index, "Button"); to != button {
+		t.Fatalf("expected the cached barrel to lead to %s, got %s", button, to)
+	}
+}
+
+func TestBuildComponentGraph_ExternalUsages(t *testing.T) {
+	dir := t.TempDir()
+	a := write(t, filepath.Join(dir, "a.tsx"), `
+        import * as Chakra from '@chakra-ui/react'
+        export function Screen(){ return <Chakra.Box/> }
+    `)
+
+	g, err := BuildComponentGraphFromEntries(context.Background(), dir, []string{a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := g.OutNeighbors(a); len(out) != 0 {
+		t.Fatalf("expected external usages dropped by default, got %v", out)
+	}
+
+	g, err = BuildComponentGraphWithOptions(context.Background(), dir, []string{a}, Options{ExternalUsages: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := g.OutNeighbors(a); len(out) != 1 || out[0] != "pkg:@chakra-ui/react" {
+		t.Fatalf("expected edge to pkg:@chakra-ui/react, got %v", out)
+	}
+}
+
+func TestBuildComponentGraph_ReportsUnresolved(t *testing.T) {
+	dir := t.TempDir()
+	a := write(t, filepath.Join(dir, "a.tsx"), `
+        import { Gone } from './gone'
+        export function A(){ return <div><Gone/><Ghost/><Local/></div> }
+        function Local(){ return null }
+    `)
+	var got []Unresolved
+	_, err := BuildComponentGraphWithOptions(context.Background(), dir, []string{a}, Options{
+		Unresolved: func(u Unresolved) { got = append(got, u) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"Gone": "./gone", "Ghost": ""}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d unresolved identifiers, got %+v", len(want), got)
+	}
+	for _, u := range got {
+		if mod, ok := want[u.Ident]; !ok || u.Module != mod || u.File != a {
+			t.Fatalf("unexpected unresolved %+v", u)
+		}
+	}
+}
+
+func TestBuildComponentGraph_ImportEdges(t *testing.T) {
+	dir := t.TempDir()
+	a := write(t, filepath.Join(dir, "a.tsx"), `
+        import { B } from './b'
+        import { C } from './c'
+        import { Props } from './types'
+        export function A(p: Props){ return <B/> }
+    `)
+	b := write(t, filepath.Join(dir, "b.tsx"), `export function B(){ return null }`)
+	c := write(t, filepath.Join(dir, "c.tsx"), `export function C(){ return null }`)
+	types := write(t, filepath.Join(dir, "types.ts"), `export interface Props { id: string }`)
+
+	g, err := BuildComponentGraphWithOptions(context.Background(), dir, []string{a}, Options{ImportEdges: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.EdgeKind(a, b); got != "rendered" {
+		t.Fatalf("a -> b kind = %q, want rendered", got)
+	}
+	if got := g.EdgeKind(a, c); got != "imported" {
+		t.Fatalf("a -> c kind = %q, want imported", got)
+	}
+	if g.Has(types) {
+		t.Fatalf("type-only import should not become a node: %v", g.Nodes())
+	}
+
+	// Without the option, unused imports stay invisible.
+	g, err = BuildComponentGraphFromEntries(context.Background(), dir, []string{a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Has(c) || g.EdgeKind(a, b) != "" {
+		t.Fatalf("expected only untagged render edges by default, got %v", g.Document().Edges)
+	}
+}
+
+func TestParseTSFile_JSXInTsFile(t *testing.T) {
+	src := []byte(`
+import { Button } from './button'
+import * as Icons from './icons'
+
+export function Toolbar() {
+	const label = <span>Save</span>
+	return <div><Button label={label} /><Icons.Save /></div>
+}
+`)
+	info, err := ParseTSFile("toolbar.ts", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ImportMap["Button"] != "./button" || info.ImportMap["Icons"] != "./icons" {
+		t.Fatalf("imports = %v", info.ImportMap)
+	}
+	if len(info.Components) != 1 || info.Components[0] != "Toolbar" {
+		t.Fatalf("components = %v, want [Toolbar]", info.Components)
+	}
+	found := false
+	for _, u := range info.JSXUsages {
+		if u.Ident == "Button" && u.Within == "Toolbar" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected <Button> within Toolbar, got %v", info.JSXUsages)
+	}
+
+	// A plain .ts cast still parses with the TypeScript grammar.
+	info, err = ParseTSFile("cast.ts", []byte("import { x } from './x'\nexport const n = <number>x\n"))
+	if err != nil || info.ImportMap["x"] != "./x" {
+		t.Fatalf("cast.ts: %v, imports = %v", err, info.ImportMap)
+	}
+}
+
+func TestBuildComponentGraph_Checkpoints(t *testing.T) {
+	dir := t.TempDir()
+	a := write(t, filepath.Join(dir, "a.tsx"), `
+		import { B } from './b'
+		export function A(){ return <B/> }
+	`)
+	write(t, filepath.Join(dir, "b.tsx"), `
+		import { C } from './c'
+		export function B(){ return <C/> }
+	`)
+	write(t, filepath.Join(dir, "c.tsx"), `export function C(){ return null }`)
+
+	for _, split := range []bool{false, true} {
+		var snapshots []*graph.Graph
+		g, err := BuildComponentGraphWithOptions(context.Background(), dir, []string{a}, Options{
+			SplitComponents: split,
+			CheckpointEvery: time.Nanosecond,
+			Checkpoint:      func(p *graph.Graph) { snapshots = append(snapshots, p) },
+		})
+		if err != nil {
+			t.Fatalf("split=%v: unexpected error: %v", split, err)
+		}
+		if len(snapshots) == 0 {
+			t.Fatalf("split=%v: expected at least one checkpoint", split)
+		}
+		// a split-mode checkpoint may point at a file not parsed yet, before its
+		// components are known
+		final := map[string]bool{}
+		for _, n := range g.Nodes() {
+			file, _, _ := strings.Cut(n, "#")
+			final[n], final[file] = true, true
+		}
+		for _, s := range snapshots {
+			for _, n := range s.Nodes() {
+				if !final[n] {
+					t.Fatalf("split=%v: checkpoint node %s isn't in the final graph %v", split, n, g.Nodes())
+				}
+			}
+		}
+		if last := snapshots[len(snapshots)-1]; len(last.Nodes()) == 0 {
+			t.Fatalf("split=%v: last checkpoint is empty", split)
+		}
+	}
+}
+
+func TestBuildComponentGraph_AliasedNamedReexport(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "tsconfig.json"), `{"compilerOptions": {"baseUrl": ".", "paths": {"@app/*": ["src/*"]}}}`)
+	page := write(t, filepath.Join(dir, "src", "page.tsx"), `
+        import { Button, PrimaryButton } from './components'
+        export function Page(){ return <><Button/><PrimaryButton/></> }
+    `)
+	write(t, filepath.Join(dir, "src", "components", "index.ts"), `
+        export { Button } from '@app/button'
+        export { default as PrimaryButton } from '@app/primary'
+    `)
+	button := write(t, filepath.Join(dir, "src", "button.tsx"), `
+        export function Button(){ return null }
+    `)
+	primary := write(t, filepath.Join(dir, "src", "primary.tsx"), `
+        export default function Primary(){ return null }
+    `)
+	g, err := BuildComponentGraphFromEntries(context.Background(), dir, []string{page})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := g.OutNeighbors(page)
+	if len(out) != 2 || out[0] != button || out[1] != primary {
+		t.Fatalf("expected edges to %s and %s through the aliased re-exports, got %v", button, primary, out)
+	}
+}
+
+func TestParseTSFile_InvalidUTF8(t *testing.T) {
+	if _, err := ParseTSFile("legacy.tsx", []byte("// caf\xe9\nimport { Phantom } from './phantom'\n")); err == nil || !strings.Contains(err.Error(), "not valid UTF-8") {
+		t.Fatalf("expected a not-UTF-8 error, got %v", err)
+	}
+}
@@ -6,40 +6,59 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	scan "github.com/philjestin/philtographer/internal/scan"
 	sitter "github.com/smacker/go-tree-sitter"
-	tsx "github.com/smacker/go-tree-sitter/typescript/tsx"
-	ts "github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
 // FileInfo contains extracted symbols for a TS/TSX file.
 type FileInfo struct {
-	Path           string
-	Components     []string          // component identifiers declared in this file
-	ImportMap      map[string]string // local name -> resolved module (raw string)
-	JSXIdentifiers []string          // JSX element names encountered (top-level identifiers)
+	Path           string            `json:"path"`
+	Components     []string          `json:"components"`     // component identifiers declared in this file
+	DefaultExport  string            `json:"defaultExport"`  // name of the default-exported declaration, if named
+	ImportMap      map[string]string `json:"importMap"`      // local name -> resolved module (raw string)
+	ImportNames    map[string]string `json:"importNames"`    // local name -> imported name ("default" or "*" for namespace)
+	JSXIdentifiers []string          `json:"jsxIdentifiers"` // JSX element names encountered (top-level identifiers)
+	JSXUsages      []JSXUsage        `json:"jsxUsages"`      // JSX element names with the component that renders them
+	StarReexports  []string          `json:"starReexports"`  // modules re-exported wholesale via export * from "..."
+	ReexportMap    map[string]string `json:"reexportMap"`    // exported name -> module, for export { X } from "..."
+	ReexportNames  map[string]string `json:"reexportNames"`  // exported name -> its name in that module ("default" for export { default as X })
+}
+
+// JSXUsage is a single JSX element reference and the declared component it appears in.
+// Within is empty when the usage isn't inside a component declaration.
+type JSXUsage struct {
+	Ident  string `json:"ident"`
+	Within string `json:"within,omitempty"`
 }
 
 // ParseTSFile extracts components, imports, and JSX tag identifiers using tree-sitter TypeScript/TSX.
 func ParseTSFile(path string, content []byte) (FileInfo, error) {
-	parser := sitter.NewParser()
-	// Choose language by extension, fallback to TSX
-	ext := strings.ToLower(filepath.Ext(path))
-	if ext == ".ts" {
-		parser.SetLanguage(ts.GetLanguage())
-	} else {
-		parser.SetLanguage(tsx.GetLanguage())
-	}
-	root := parser.Parse(nil, content)
+	// tree-sitter would parse garbage out of a Latin-1 or UTF-16 file
+	if !utf8.Valid(content) {
+		return FileInfo{}, fmt.Errorf("parse %s: %s", path, scan.ReasonNotUTF8)
+	}
+	// a BOM would otherwise open the tree with an ERROR node
+	content = scan.NormalizeSource(content)
+	// TypeScript grammar for .ts (TSX if it has JSX anyway), TSX for everything else
+	root := scan.ParseSource(path, content)
 	if root == nil {
 		return FileInfo{}, fmt.Errorf("parse failed: %s", path)
 	}
 
-	info := FileInfo{Path: path, ImportMap: map[string]string{}}
+	info := FileInfo{
+		Path:          path,
+		ImportMap:     map[string]string{},
+		ImportNames:   map[string]string{},
+		ReexportMap:   map[string]string{},
+		ReexportNames: map[string]string{},
+	}
 
-	var walk func(n *sitter.Node)
-	walk = func(n *sitter.Node) {
+	// within is the component declaration enclosing n, used to attribute JSX usages.
+	var walk func(n *sitter.Node, within string)
+	walk = func(n *sitter.Node, within string) {
 		if !n.IsNamed() {
 			return
 		}
@@ -54,39 +73,88 @@ func ParseTSFile(path string, content []byte) (FileInfo, error) {
 				// default import: import Foo from "..."
 				if id := findChild(clause, "identifier"); id != nil {
 					info.ImportMap[nodeText(content, id)] = mod
+					info.ImportNames[nodeText(content, id)] = "default"
 				}
 				// namespace import: import * as NS from "..."
 				if ns := findChild(clause, "namespace_import"); ns != nil {
 					if nid := findChild(ns, "identifier"); nid != nil {
 						info.ImportMap[nodeText(content, nid)] = mod
+						info.ImportNames[nodeText(content, nid)] = "*"
 					}
 				}
 				if nb := findChild(clause, "named_imports"); nb != nil {
 					for i := 0; i < int(nb.NamedChildCount()); i++ {
 						el := nb.NamedChild(i)
 						if el.Type() == "import_specifier" {
-							name := findChildContent(content, el, "identifier")
-							as := findChild(el, "as_clause")
-							if as != nil {
-								if aid := findChild(as, "identifier"); aid != nil {
-									name = nodeText(content, aid)
-								}
+							imported := findChildContent(content, el, "identifier")
+							name := imported
+							// import { A as B }: the alias is the local binding
+							if alias := el.ChildByFieldName("alias"); alias != nil {
+								name = nodeText(content, alias)
 							}
 							if name != "" {
 								info.ImportMap[name] = mod
+								info.ImportNames[name] = imported
 							}
 						}
 					}
 				}
 			}
 
+		case "export_statement":
+			// export * from "module" (but not export * as NS / export { X } from)
+			if src := n.ChildByFieldName("source"); src != nil &&
+				findChild(n, "export_clause") == nil && findChild(n, "namespace_export") == nil {
+				if mod := strings.Trim(nodeText(content, src), "'\""); mod != "" {
+					info.StarReexports = append(info.StarReexports, mod)
+				}
+			}
+			// export { A, B as C } from "module"
+			if src := n.ChildByFieldName("source"); src != nil {
+				if clause := findChild(n, "export_clause"); clause != nil {
+					mod := strings.Trim(nodeText(content, src), "'\"")
+					for i := 0; i < int(clause.NamedChildCount()); i++ {
+						el := clause.NamedChild(i)
+						if el.Type() != "export_specifier" {
+							continue
+						}
+						name := el.ChildByFieldName("name")
+						if name == nil || mod == "" {
+							continue
+						}
+						exported := nodeText(content, name)
+						if alias := el.ChildByFieldName("alias"); alias != nil {
+							info.ReexportMap[nodeText(content, alias)] = mod
+							info.ReexportNames[nodeText(content, alias)] = exported
+						} else {
+							info.ReexportMap[exported] = mod
+							info.ReexportNames[exported] = exported
+						}
+					}
+				}
+			}
+			// export default function Foo() {} / export default Foo
+			if strings.HasPrefix(nodeText(content, n), "export default") {
+				if decl := n.ChildByFieldName("declaration"); decl != nil {
+					if id := findChild(decl, "identifier"); id != nil {
+						info.DefaultExport = nodeText(content, id)
+					}
+				} else if v := n.ChildByFieldName("value"); v != nil && v.Type() == "identifier" {
+					info.DefaultExport = nodeText(content, v)
+				}
+			}
 		case "function_declaration":
 			if id := findChild(n, "identifier"); id != nil {
 				name := nodeText(content, id)
 				if isComponentName(name) {
 					info.Components = append(info.Components, name)
+					within = name
 				}
 			}
+		case "variable_declarator":
+			if id := findChild(n, "identifier"); id != nil && isComponentName(nodeText(content, id)) {
+				within = nodeText(content, id)
+			}
 		case "lexical_declaration":
 			for i := 0; i < int(n.NamedChildCount()); i++ {
 				vd := n.NamedChild(i)
@@ -104,13 +172,14 @@ func ParseTSFile(path string, content []byte) (FileInfo, error) {
 		case "jsx_opening_element", "jsx_self_closing_element":
 			if ident := jsxHeadIdent(content, n); ident != "" {
 				info.JSXIdentifiers = append(info.JSXIdentifiers, ident)
+				info.JSXUsages = append(info.JSXUsages, JSXUsage{Ident: ident, Within: within})
 			}
 		}
 		for i := 0; i < int(n.NamedChildCount()); i++ {
-			walk(n.NamedChild(i))
+			walk(n.NamedChild(i), within)
 		}
 	}
-	walk(root.RootNode())
+	walk(root.RootNode(), "")
 
 	return info, nil
 }
@@ -232,4 +301,101 @@ func ResolveImportedComponent(currentFile string, importMap map[string]string, i
 	return ""
 }
 
+// ResolveReexportedComponent follows re-exports from file to the file that declares
+// component name: export { name } from "..." (also renamed, export { X as name }) and
+// export * from "...". Re-exported modules resolve like imports, so aliases work. It
+// returns file itself when name is declared there or when no re-export leads to a
+// declaration.
+func ResolveReexportedComponent(file, name string) string {
+	return newReexportCache().resolve(file, name)
+}
+
+// reexportCache holds the declarations and re-exports parsed from each barrel file,
+// so a build following many usages through the same barrel reads it once. It is
+// safe for concurrent use.
+type reexportCache struct {
+	mu    sync.Mutex
+	files map[string]*FileInfo // nil when the file can't be read or parsed
+}
+
+func newReexportCache() *reexportCache {
+	return &reexportCache{files: map[string]*FileInfo{}}
+}
+
+// resolve is ResolveReexportedComponent through the cache.
+func (c *reexportCache) resolve(file, name string) string {
+	if name == "" || name == "default" || name == "*" {
+		return file
+	}
+	if to := c.follow(file, name, map[string]bool{}); to != "" {
+		return to
+	}
+	return file
+}
+
+// info returns the parsed exports of file, or nil when it can't be parsed.
+func (c *reexportCache) info(file string) *FileInfo {
+	c.mu.Lock()
+	fi, ok := c.files[file]
+	c.mu.Unlock()
+	if ok {
+		return fi
+	}
+	if data, err := os.ReadFile(file); err == nil {
+		if parsed, err := ParseTSFile(file, data); err == nil {
+			// keep only what following re-exports needs
+			fi = &FileInfo{
+				Components:    parsed.Components,
+				StarReexports: parsed.StarReexports,
+				ReexportMap:   parsed.ReexportMap,
+				ReexportNames: parsed.ReexportNames,
+			}
+		}
+	}
+	c.mu.Lock()
+	c.files[file] = fi
+	c.mu.Unlock()
+	return fi
+}
+
+func (c *reexportCache) follow(file, name string, seen map[string]bool) string {
+	if seen[file] {
+		return ""
+	}
+	seen[file] = true
+	fi := c.info(file)
+	if fi == nil {
+		return ""
+	}
+	for _, comp := range fi.Components {
+		if comp == name {
+			return file
+		}
+	}
+	if mod, ok := fi.ReexportMap[name]; ok {
+		to := ResolveImportedComponent(file, map[string]string{name: mod}, name)
+		if to == "" || isExternal(to) {
+			return ""
+		}
+		// the name comes from that module even when its declaration isn't found
+		// there (export { default as name }, or a non-component declaration)
+		if orig := fi.ReexportNames[name]; orig != "default" {
+			if found := c.follow(to, orig, seen); found != "" {
+				return found
+			}
+		}
+		return to
+	}
+	for _, mod := range fi.StarReexports {
+		to := ResolveImportedComponent(file, map[string]string{name: mod}, name)
+		if to == "" {
+			continue
+		}
+		if found := c.follow(to, name, seen); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
 func fileExists(p string) bool { _, err := os.Stat(p); return err == nil }